@@ -0,0 +1,52 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/jamesBoder/rss_aggreggator/internal/feed"
+)
+
+// discoverFeed returns the URL of the feed behind rawURL along with the parsed feed. when rawURL is an HTML page, the feeds it advertises are tried best first, then the common feed paths of the site. the first candidate that parses as a feed is selected. a feed that was moved permanently is returned under its new URL, so the old one is never stored
+func discoverFeed(ctx context.Context, s *state, rawURL string) (string, *feed.Feed, error) {
+	result, err := s.fetcher.fetchFeed(ctx, rawURL, "", "")
+	if err == nil {
+		return finalURL(rawURL, result), result.Feed, nil
+	}
+
+	var page *htmlPageError
+	if !errors.As(err, &page) {
+		return "", nil, fmt.Errorf("error fetching feed: %v", err)
+	}
+
+	candidates := feed.Discover(page.Body, page.URL)
+	if len(candidates) == 0 {
+		fmt.Printf("%s does not advertise a feed, trying common feed locations\n", page.URL)
+		candidates = feed.CommonCandidates(page.URL)
+	} else {
+		fmt.Printf("Found %d feed(s) on %s:\n", len(candidates), page.URL)
+		for _, c := range candidates {
+			fmt.Printf("  - %s (%s) %s\n", c.URL, c.Type, c.Title)
+		}
+	}
+
+	for _, c := range candidates {
+		result, err := s.fetcher.fetchFeed(ctx, c.URL, "", "")
+		if err != nil {
+			continue
+		}
+		feedURL := finalURL(c.URL, result)
+		fmt.Printf("Using feed %s\n", feedURL)
+		return feedURL, result.Feed, nil
+	}
+	return "", nil, fmt.Errorf("no feed found at %s", rawURL)
+}
+
+// finalURL returns the URL a fetch of feedURL ended up at through permanent redirects
+func finalURL(feedURL string, result *fetchResult) string {
+	if result.MovedTo != "" {
+		return result.MovedTo
+	}
+	return feedURL
+}
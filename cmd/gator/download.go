@@ -0,0 +1,181 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/jamesBoder/rss_aggreggator/internal/database"
+)
+
+// handlerDownload saves an enclosure of a post, e.g. a podcast episode, to the download directory. usage: download <post-id> [enclosure-number]. the first enclosure is used by default. an interrupted download resumes where it stopped when the command is run again
+func handlerDownload(s *state, cmd command) error {
+	if len(cmd.Args) < 1 || len(cmd.Args) > 2 {
+		return fmt.Errorf("usage: download <post-id> [enclosure-number]")
+	}
+	postID, err := uuid.Parse(cmd.Args[0])
+	if err != nil {
+		return fmt.Errorf("invalid post id: %s", cmd.Args[0])
+	}
+	number := 1
+	if len(cmd.Args) == 2 {
+		number, err = strconv.Atoi(cmd.Args[1])
+		if err != nil {
+			return fmt.Errorf("invalid enclosure number: %s", cmd.Args[1])
+		}
+	}
+
+	ctx := context.Background()
+	enclosures, err := s.db.GetPostEnclosures(ctx, postID)
+	if err != nil {
+		return fmt.Errorf("error fetching enclosures: %v", err)
+	}
+	if len(enclosures) == 0 {
+		return fmt.Errorf("post %s has no enclosures", postID)
+	}
+	if number < 1 || number > len(enclosures) {
+		return fmt.Errorf("post %s has %d enclosure(s)", postID, len(enclosures))
+	}
+	enclosure := enclosures[number-1]
+
+	dir, err := s.cfg.DownloadDirOrDefault()
+	if err != nil {
+		return fmt.Errorf("error finding download directory: %v", err)
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return fmt.Errorf("error creating download directory: %v", err)
+	}
+
+	dest := filepath.Join(dir, downloadName(postID, enclosure))
+	if _, err := os.Stat(dest); err == nil {
+		fmt.Printf("Already downloaded: %s\n", dest)
+		return nil
+	}
+
+	fmt.Printf("Downloading %s\n", enclosure.Url)
+	size, err := s.fetcher.download(ctx, enclosure.Url, dest)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Saved %s (%s)\n", dest, formatBytes(size))
+	return nil
+}
+
+// downloadName names the file an enclosure is saved as: the file name from its URL, prefixed with the start of the post id since podcasts often reuse names such as "episode.mp3". the name only depends on the post and the URL, so a later run finds the partial file again
+func downloadName(postID uuid.UUID, enclosure database.PostEnclosure) string {
+	name := ""
+	if u, err := url.Parse(enclosure.Url); err == nil {
+		name = path.Base(u.Path)
+	}
+	if name == "" || name == "." || name == "/" {
+		name = "enclosure"
+		if exts, _ := mime.ExtensionsByType(enclosure.MimeType.String); len(exts) > 0 {
+			name += exts[0]
+		}
+	}
+	// never let a crafted URL escape the download directory
+	name = strings.NewReplacer("/", "_", "\\", "_").Replace(name)
+	return postID.String()[:8] + "-" + name
+}
+
+// download saves rawURL to dest and returns its size. the body is written to dest + ".part" first and renamed once complete. when a partial file is left from an earlier attempt, only the rest of the file is requested with a Range header
+func (f *fetcher) download(ctx context.Context, rawURL, dest string) (int64, error) {
+	partial := dest + ".part"
+	var offset int64
+	if info, err := os.Stat(partial); err == nil {
+		offset = info.Size()
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "GET", rawURL, nil)
+	if err != nil {
+		return 0, fmt.Errorf("error creating request: %v", err)
+	}
+	req.Header.Set("User-Agent", f.userAgent)
+	if offset > 0 {
+		req.Header.Set("Range", fmt.Sprintf("bytes=%d-", offset))
+	}
+
+	// an episode can take far longer to download than a feed, so drop the overall timeout. the transport still gives up on servers that never answer
+	client := *f.clientFor(rawURL)
+	client.Timeout = 0
+	resp, err := client.Do(req)
+	if err != nil {
+		return 0, fmt.Errorf("error downloading: %v", err)
+	}
+	defer resp.Body.Close()
+
+	flags := os.O_CREATE | os.O_WRONLY
+	switch resp.StatusCode {
+	case http.StatusPartialContent:
+		if !strings.HasPrefix(resp.Header.Get("Content-Range"), fmt.Sprintf("bytes %d-", offset)) {
+			return 0, fmt.Errorf("server resumed at the wrong offset: %s", resp.Header.Get("Content-Range"))
+		}
+		fmt.Printf("Resuming after %s\n", formatBytes(offset))
+		flags |= os.O_APPEND
+	case http.StatusOK:
+		// the server ignores ranges, so start over
+		offset = 0
+		flags |= os.O_TRUNC
+	case http.StatusRequestedRangeNotSatisfiable:
+		// the partial file may already hold the whole file, which Content-Range confirms with "bytes */<size>"
+		if offset > 0 && resp.Header.Get("Content-Range") == fmt.Sprintf("bytes */%d", offset) {
+			return offset, os.Rename(partial, dest)
+		}
+		os.Remove(partial)
+		return 0, fmt.Errorf("the partial download no longer matches the file on the server, run the command again to start over")
+	default:
+		return 0, &httpStatusError{StatusCode: resp.StatusCode}
+	}
+
+	file, err := os.OpenFile(partial, flags, 0o644)
+	if err != nil {
+		return 0, fmt.Errorf("error opening download file: %v", err)
+	}
+	written, err := io.Copy(file, resp.Body)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return offset + written, fmt.Errorf("download interrupted after %s, run the command again to resume: %v", formatBytes(offset+written), err)
+	}
+
+	if err := os.Rename(partial, dest); err != nil {
+		return offset + written, fmt.Errorf("error saving download: %v", err)
+	}
+	return offset + written, nil
+}
+
+// formatBytes renders a size for people, e.g. "34.2 MB"
+func formatBytes(n int64) string {
+	const unit = 1000
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	value, prefix := float64(n)/unit, 0
+	for value >= unit && prefix < 4 {
+		value /= unit
+		prefix++
+	}
+	return fmt.Sprintf("%.1f %cB", value, "kMGTP"[prefix])
+}
+
+// formatDuration renders a playing time the way podcast apps do, e.g. "1:02:03" or "45:12"
+func formatDuration(d time.Duration) string {
+	total := int(d.Round(time.Second) / time.Second)
+	hours, minutes, seconds := total/3600, total/60%60, total%60
+	if hours > 0 {
+		return fmt.Sprintf("%d:%02d:%02d", hours, minutes, seconds)
+	}
+	return fmt.Sprintf("%d:%02d", minutes, seconds)
+}
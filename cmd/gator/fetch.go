@@ -0,0 +1,288 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"os"
+	"regexp"
+	"time"
+
+	"github.com/jamesBoder/rss_aggreggator/internal/config"
+
+	"github.com/jamesBoder/rss_aggreggator/internal/feed"
+)
+
+// fetcher holds the HTTP clients shared by every feed fetch, so connections are pooled and reused across fetches and workers
+type fetcher struct {
+	client    *http.Client
+	insecure  *http.Client    // same settings as client but without TLS certificate verification
+	skipTLS   map[string]bool // feed URLs and hostnames fetched with insecure
+	userAgent string
+	maxBytes  int64
+	maxItems  int
+}
+
+// newFetcher builds the shared clients from the fetcher section of the config
+func newFetcher(cfg config.FetcherConfig) (*fetcher, error) {
+	timeout, err := cfg.TimeoutDuration()
+	if err != nil {
+		return nil, err
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.MaxIdleConns = 100
+	transport.MaxIdleConnsPerHost = 4
+	transport.IdleConnTimeout = 90 * time.Second
+	transport.ResponseHeaderTimeout = timeout
+
+	if cfg.Proxy != "" {
+		proxyURL, err := url.Parse(cfg.Proxy)
+		if err != nil || proxyURL.Host == "" {
+			return nil, fmt.Errorf("invalid fetcher proxy %q", cfg.Proxy)
+		}
+		transport.Proxy = http.ProxyURL(proxyURL)
+	}
+
+	tlsConfig := &tls.Config{MinVersion: tls.VersionTLS12}
+	if cfg.CABundle != "" {
+		pem, err := os.ReadFile(cfg.CABundle)
+		if err != nil {
+			return nil, fmt.Errorf("error reading CA bundle: %v", err)
+		}
+		pool, err := x509.SystemCertPool()
+		if err != nil {
+			pool = x509.NewCertPool()
+		}
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificates found in CA bundle %s", cfg.CABundle)
+		}
+		tlsConfig.RootCAs = pool
+	}
+	transport.TLSClientConfig = tlsConfig
+
+	f := &fetcher{
+		client:    &http.Client{Transport: transport, Timeout: timeout},
+		skipTLS:   make(map[string]bool),
+		userAgent: cfg.UserAgentOrDefault(),
+		maxBytes:  cfg.MaxResponseBytesOrDefault(),
+		maxItems:  cfg.MaxItemsOrDefault(),
+	}
+
+	// only build the unverified client when some feed needs it
+	if len(cfg.InsecureSkipVerify) > 0 {
+		insecureTransport := transport.Clone()
+		insecureTransport.TLSClientConfig.InsecureSkipVerify = true
+		f.insecure = &http.Client{Transport: insecureTransport, Timeout: timeout}
+		for _, entry := range cfg.InsecureSkipVerify {
+			f.skipTLS[entry] = true
+		}
+	}
+	return f, nil
+}
+
+// clientFor returns the client to fetch feedURL with. feeds listed in insecure_skip_verify, by URL or by hostname, get the client that does not verify certificates
+func (f *fetcher) clientFor(feedURL string) *http.Client {
+	if f.insecure != nil && (f.skipTLS[feedURL] || f.skipTLS[feedHost(feedURL)]) {
+		return f.insecure
+	}
+	return f.client
+}
+
+// httpStatusError is returned by fetchFeed for any response other than 200 or 304, so callers can react to specific codes such as 410 Gone
+type httpStatusError struct {
+	StatusCode int
+}
+
+func (e *httpStatusError) Error() string {
+	return fmt.Sprintf("non-200 response: %d", e.StatusCode)
+}
+
+// htmlPageError is returned by fetchFeed when the URL serves an HTML page instead of a feed. it keeps the page so the feeds it links to can be discovered
+type htmlPageError struct {
+	URL  string // the page URL after redirects, used to resolve relative links
+	Body []byte
+}
+
+func (e *htmlPageError) Error() string {
+	return fmt.Sprintf("%s is an HTML page, not a feed", e.URL)
+}
+
+// fetchResult holds the outcome of a fetch along with the caching headers to send on the next one
+type fetchResult struct {
+	Feed         *feed.Feed // nil when NotModified is set
+	NotModified  bool       // the server answered 304, nothing changed since the last fetch
+	ETag         string
+	LastModified string
+	MovedTo      string // set when the feed URL was permanently redirected (301/308)
+}
+
+// permanentRedirectTarget follows the redirect chain that led to resp and returns the URL reached through permanent redirects (301/308) only. the chain stops at the first temporary redirect, since its target must not be remembered. it returns "" if the first hop was not a permanent redirect
+func permanentRedirectTarget(resp *http.Response) string {
+	// walk back from the final request to the original one
+	var chain []*http.Request
+	for req := resp.Request; req != nil; {
+		chain = append(chain, req)
+		if req.Response == nil {
+			break
+		}
+		req = req.Response.Request
+	}
+
+	target := ""
+	for i := len(chain) - 2; i >= 0; i-- {
+		code := chain[i].Response.StatusCode
+		if code != http.StatusMovedPermanently && code != http.StatusPermanentRedirect {
+			break
+		}
+		target = chain[i].URL.String()
+	}
+	return target
+}
+
+// create fetchFeed function. Fetch a feed from the URL and return a fetchResult struct pointer and error. etag and lastModified come from the previous fetch and are sent as If-None-Match/If-Modified-Since
+func (f *fetcher) fetchFeed(ctx context.Context, feedURL, etag, lastModified string) (*fetchResult, error) {
+	// http.NewRequestWithContext to create a new GET request with the given context and feedURL
+	req, err := http.NewRequestWithContext(ctx, "GET", feedURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("error creating request: %v", err)
+	}
+
+	// set the configured User-Agent header with request.Header.Set
+	req.Header.Set("User-Agent", f.userAgent)
+	req.Header.Set("Accept", "application/rss+xml, application/atom+xml, application/feed+json, application/xml;q=0.9, */*;q=0.8")
+
+	// make the request conditional so unchanged feeds cost a 304 instead of the full body
+	if etag != "" {
+		req.Header.Set("If-None-Match", etag)
+	}
+	if lastModified != "" {
+		req.Header.Set("If-Modified-Since", lastModified)
+	}
+
+	// send the request with the shared client, or its unverified twin for feeds listed in insecure_skip_verify
+	resp, err := f.clientFor(feedURL).Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("error fetching feed: %v", err)
+	}
+
+	// ensure resp.Body is closed after reading
+	defer resp.Body.Close()
+
+	result := &fetchResult{
+		ETag:         resp.Header.Get("ETag"),
+		LastModified: resp.Header.Get("Last-Modified"),
+		MovedTo:      permanentRedirectTarget(resp),
+	}
+
+	// a 304 means our cached copy is current. servers may omit the validators on a 304, so keep the ones we sent
+	if resp.StatusCode == http.StatusNotModified {
+		result.NotModified = true
+		if result.ETag == "" {
+			result.ETag = etag
+		}
+		if result.LastModified == "" {
+			result.LastModified = lastModified
+		}
+		return result, nil
+	}
+
+	// check if response status code is 200
+	if resp.StatusCode != 200 {
+		return nil, &httpStatusError{StatusCode: resp.StatusCode}
+	}
+
+	// refuse bodies announced as larger than max_response_bytes before reading any of them
+	if resp.ContentLength > f.maxBytes {
+		return nil, fmt.Errorf("%w: response of %d bytes exceeds the %d byte limit", feed.ErrTooLarge, resp.ContentLength, f.maxBytes)
+	}
+
+	// peek at the start of the body to tell a web page from a feed without reading it all
+	body := bufio.NewReader(resp.Body)
+	head, _ := body.Peek(512)
+
+	// a web page instead of a feed: keep it so callers can look for the feeds it links to. the feed links are in its head, so a page cut at the size limit is still usable
+	contentType := resp.Header.Get("Content-Type")
+	if feed.IsHTML(contentType, head) {
+		page, err := io.ReadAll(io.LimitReader(body, f.maxBytes))
+		if err != nil {
+			return nil, fmt.Errorf("error reading response body: %v", err)
+		}
+		return nil, &htmlPageError{URL: resp.Request.URL.String(), Body: page}
+	}
+
+	// use feed.ParseReader to stream the body (RSS 2.0, RSS 1.0, Atom or JSON Feed) into a feed.Feed struct. it fails once the body passes max_response_bytes and stops reading after max_items items, so memory stays bounded however large the document is. titles come back as plain text, with their entities decoded by the parser of each format
+	parsed, err := feed.ParseReader(body, contentType, feed.Limits{MaxBytes: f.maxBytes, MaxItems: f.maxItems})
+	if err != nil {
+		return nil, err
+	}
+
+	// return the fetchResult struct pointer
+	result.Feed = parsed
+	return result, nil
+}
+
+// metaCharset finds the charset an HTML page declares in its head, with <meta charset> or the older http-equiv Content-Type form
+var metaCharset = regexp.MustCompile(`(?i)<meta[^>]+charset\s*=\s*["']?([A-Za-z0-9._:-]+)`)
+
+// fetchArticle downloads the web page a post links to and returns it as UTF-8 along with its final URL, which relative links in it are resolved against
+func (f *fetcher) fetchArticle(ctx context.Context, pageURL string) (string, string, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", pageURL, nil)
+	if err != nil {
+		return "", "", fmt.Errorf("error creating request: %v", err)
+	}
+	req.Header.Set("User-Agent", f.userAgent)
+	req.Header.Set("Accept", "text/html, application/xhtml+xml;q=0.9")
+
+	resp, err := f.clientFor(pageURL).Do(req)
+	if err != nil {
+		return "", "", fmt.Errorf("error fetching article: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != 200 {
+		return "", "", &httpStatusError{StatusCode: resp.StatusCode}
+	}
+	mediaType, params, _ := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	if mediaType != "" && mediaType != "text/html" && mediaType != "application/xhtml+xml" {
+		return "", "", fmt.Errorf("article is not a web page: %s", mediaType)
+	}
+	if resp.ContentLength > f.maxBytes {
+		return "", "", fmt.Errorf("%w: response of %d bytes exceeds the %d byte limit", feed.ErrTooLarge, resp.ContentLength, f.maxBytes)
+	}
+
+	page, err := io.ReadAll(io.LimitReader(resp.Body, f.maxBytes+1))
+	if err != nil {
+		return "", "", fmt.Errorf("error reading article: %v", err)
+	}
+	if int64(len(page)) > f.maxBytes {
+		return "", "", fmt.Errorf("%w: larger than %d bytes", feed.ErrTooLarge, f.maxBytes)
+	}
+
+	// the header names the charset first, then the page itself near its start. a label that names no known encoding is passed over, and a page without a usable one is taken as UTF-8
+	labels := []string{params["charset"]}
+	if m := metaCharset.FindSubmatch(page[:min(len(page), 1024)]); m != nil {
+		labels = append(labels, string(m[1]))
+	}
+	for _, label := range labels {
+		if label == "" {
+			continue
+		}
+		r, err := feed.NewUTF8Reader(bytes.NewReader(page), label)
+		if err != nil {
+			continue
+		}
+		if page, err = io.ReadAll(r); err != nil {
+			return "", "", fmt.Errorf("error decoding article: %v", err)
+		}
+		break
+	}
+	return string(page), resp.Request.URL.String(), nil
+}
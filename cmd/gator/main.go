@@ -3,14 +3,14 @@ package main
 import (
 	"context"
 	"database/sql"
-	"encoding/xml"
+	"errors"
+	"flag"
 	"fmt"
-	"html"
-	"io"
 	"log"
 	"net/http"
 	"os"
 	"path/filepath"
+	"slices"
 	"sort"
 	"strconv"
 	"strings"
@@ -20,9 +20,17 @@ import (
 
 	"github.com/google/uuid"
 
+	"golang.org/x/term"
+
 	"github.com/jamesBoder/rss_aggreggator/internal/config"
 
 	"github.com/jamesBoder/rss_aggreggator/internal/database"
+
+	"github.com/jamesBoder/rss_aggreggator/internal/feed"
+
+	"github.com/jamesBoder/rss_aggreggator/internal/htmlutil"
+
+	"github.com/jamesBoder/rss_aggreggator/internal/schedule"
 )
 
 // Create a state struct that holds a pointer to a config struct
@@ -34,6 +42,12 @@ type state struct {
 
 	// point to raw *sql.DB
 	dbSQL *sql.DB
+
+	// bounds for per-feed refresh intervals
+	policy schedule.Policy
+
+	// shared HTTP clients used for every feed fetch
+	fetcher *fetcher
 }
 
 // Create a command struct. Contains a name and a slice of string args
@@ -47,6 +61,22 @@ type commands struct {
 	Handlers map[string]func(*state, command) error
 }
 
+// parseFlags parses args with fs and returns the positional arguments. unlike fs.Parse, flags may appear before, between or after positional arguments
+func parseFlags(fs *flag.FlagSet, args []string) ([]string, error) {
+	var positional []string
+	for {
+		if err := fs.Parse(args); err != nil {
+			return nil, err
+		}
+		args = fs.Args()
+		if len(args) == 0 {
+			return positional, nil
+		}
+		positional = append(positional, args[0])
+		args = args[1:]
+	}
+}
+
 // implement register method
 func (c *commands) register(name string, f func(*state, command) error) {
 	c.Handlers[name] = f
@@ -200,115 +230,90 @@ func handlerUsers(state *state, command command) error {
 	return nil
 }
 
-// define RSSfeed struct to hold feed data
-type RSSFeed struct {
-	Channel struct {
-		Title       string    `xml:"title"`
-		Link        string    `xml:"link"`
-		Description string    `xml:"description"`
-		Item        []RSSItem `xml:"item"`
-	} `xml:"channel"`
-}
-
-// define RSSItem struct to hold individual feed items
-type RSSItem struct {
-	Title       string `xml:"title"`
-	Link        string `xml:"link"`
-	Description string `xml:"description"`
-	PubDate     string `xml:"pubDate"`
-}
-
-// create fetchFeed function. Fetch a feed from the URL and return a RSSfeed struct pointer and error
-func fetchFeed(ctx context.Context, feedURL string) (*RSSFeed, error) {
-	// http.NewRequestWithContext to create a new GET request with the given context and feedURL
-	req, err := http.NewRequestWithContext(ctx, "GET", feedURL, nil)
-	if err != nil {
-		return nil, fmt.Errorf("error creating request: %v", err)
-	}
-
-	// set User-Agent header to "gator" with request.Header.Set
-	req.Header.Set("User-Agent", "gator")
-
-	// use http.Client.Do to send the request and get a response
-	client := &http.Client{}
-	resp, err := client.Do(req)
+// update handlerAgg command to take a single argument: time_between_reqs, how often to look for due feeds. it should print a message when it starts. Use time.Ticker to run a scrape round at the given interval. Print a message each time before scraping. if time_between_reqs is not provided, default to 10 seconds. --concurrency sets the number of workers and --per-host caps how many of them fetch from the same host at once. each feed is refreshed on its own schedule, bounded by --min-interval and --max-interval. after each round, up to --max-articles articles are downloaded for feeds in full content mode
+func handlerAgg(state *state, command command) error {
+	fs := flag.NewFlagSet("agg", flag.ContinueOnError)
+	concurrency := fs.Int("concurrency", 4, "number of feeds to fetch in parallel")
+	perHost := fs.Int("per-host", 2, "maximum parallel fetches against a single host")
+	maxArticles := fs.Int("max-articles", 20, "maximum number of articles downloaded per round for feeds in full content mode")
+	fs.DurationVar(&state.policy.Min, "min-interval", state.policy.Min, "shortest time between two fetches of a feed")
+	fs.DurationVar(&state.policy.Max, "max-interval", state.policy.Max, "longest time between two fetches of a feed")
+	args, err := parseFlags(fs, command.Args)
 	if err != nil {
-		return nil, fmt.Errorf("error fetching feed: %v", err)
-	}
-
-	// ensure resp.Body is closed after reading
-	defer resp.Body.Close()
-
-	// check if response status code is 200
-	if resp.StatusCode != 200 {
-		return nil, fmt.Errorf("non-200 response: %d", resp.StatusCode)
+		return err
 	}
-
-	// use io.ReadAll to read the response body
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return nil, fmt.Errorf("error reading response body: %v", err)
+	if *concurrency < 1 || *perHost < 1 {
+		return fmt.Errorf("--concurrency and --per-host must be at least 1")
 	}
-
-	// use xml.Unmarshal to parse the body into a RSSfeed struct
-	var feed RSSFeed
-	if err := xml.Unmarshal(body, &feed); err != nil {
-		return nil, fmt.Errorf("error unmarshaling feed: %v", err)
+	if *maxArticles < 0 {
+		return fmt.Errorf("--max-articles must not be negative")
 	}
-
-	// ensure both Title and Description are decoded using html.UnescapeString. Make sure no case changes, trimming, or other modifications are made
-	feed.Channel.Title = decodeHTMLEntities(feed.Channel.Title)
-	feed.Channel.Description = decodeHTMLEntities(feed.Channel.Description)
-	for i := range feed.Channel.Item {
-		feed.Channel.Item[i].Title = decodeHTMLEntities(feed.Channel.Item[i].Title)
-		feed.Channel.Item[i].Description = decodeHTMLEntities(feed.Channel.Item[i].Description)
+	if state.policy.Min <= 0 || state.policy.Max < state.policy.Min {
+		return fmt.Errorf("--min-interval must be positive and not greater than --max-interval")
 	}
 
-	// return the RSSfeed struct pointer
-
-	return &feed, nil
-}
-
-// use html.UnescapeString to decode escaped HTML entities. Run Title and Description through this function before storing or displaying
-func decodeHTMLEntities(s string) string {
-	return html.UnescapeString(s)
-}
-
-// update handlerAgg command to take a single argument: time_between_reqs. it should print a message when it starts. Use time.Ticker to run scrapeFeeds function at the given interval. Print a message each time before calling scrapeFeeds. if time_between_reqs is not provided, default to 10 seconds.
-func handlerAgg(state *state, command command) error {
 	interval := 10 * time.Second
-	if len(command.Args) >= 1 {
-		dur, err := time.ParseDuration(command.Args[0])
+	if len(args) >= 1 {
+		dur, err := time.ParseDuration(args[0])
 		if err != nil {
 			return fmt.Errorf("invalid duration: %v", err)
 		}
 		interval = dur
 	}
 
-	fmt.Printf("Starting aggregator with interval: %s\n", interval)
+	fmt.Printf("Starting aggregator with interval: %s, workers: %d, per host: %d, feed refresh: %s-%s\n", interval, *concurrency, *perHost, state.policy.Min, state.policy.Max)
 
 	ticker := time.NewTicker(interval)
 	defer ticker.Stop()
 
+	limiter := newHostLimiter(*perHost)
 	for {
 		fmt.Println("Scraping feeds...")
-		if err := scrapeFeeds(state); err != nil {
-			fmt.Printf("Error scraping feeds: %v\n", err)
-		}
+		scrapeRound(context.Background(), state, *concurrency, limiter)
+		fetchArticles(context.Background(), state, *concurrency, *maxArticles, limiter)
 		<-ticker.C
 	}
 }
 
-// add command addFeed. It takes the name of the feed and the URL as arguments. At the top of the handler, get current user from the database and connect the feedto that user. the print out the fields of the new feed record.
+// add command addFeed. It takes an optional name for the feed and the URL as arguments. The URL is fetched and parsed first, so typos fail here rather than at scrape time; the name defaults to the feed's title and its first batch of items is stored right away. --no-validate skips the fetch for offline use, in which case the name is required. At the top of the handler, get current user from the database and connect the feed to that user. the print out the fields of the new feed record.
 func handlerAddFeed(state *state, command command, user database.User) error {
+	fs := flag.NewFlagSet("addfeed", flag.ContinueOnError)
+	noValidate := fs.Bool("no-validate", false, "store the feed without fetching it")
+	args, err := parseFlags(fs, command.Args)
+	if err != nil {
+		return err
+	}
 
-	// get current user from the database
-	if len(command.Args) < 2 {
-		return fmt.Errorf("feed name and URL arguments are required")
+	var feedName, feedURL string
+	switch len(args) {
+	case 1:
+		feedURL = args[0]
+	case 2:
+		feedName, feedURL = args[0], args[1]
+	default:
+		return fmt.Errorf("usage: addfeed [--no-validate] [name] <url>")
 	}
 
-	feedName := command.Args[0]
-	feedURL := command.Args[1]
+	ctx := context.Background()
+
+	var parsed *feed.Feed
+	if *noValidate {
+		if feedName == "" {
+			return fmt.Errorf("a feed name is required with --no-validate")
+		}
+	} else {
+		// the user may have pasted the site's homepage rather than its feed
+		feedURL, parsed, err = discoverFeed(ctx, state, feedURL)
+		if err != nil {
+			return err
+		}
+		if feedName == "" {
+			feedName = parsed.Title
+		}
+		if feedName == "" {
+			return fmt.Errorf("the feed has no title, please provide a name")
+		}
+	}
 
 	params := database.CreateFeedParams{
 		ID:        uuid.New(),
@@ -318,28 +323,44 @@ func handlerAddFeed(state *state, command command, user database.User) error {
 		Name:      feedName,
 		Url:       feedURL,
 	}
+	if parsed != nil {
+		params.SiteUrl = sql.NullString{String: parsed.Link, Valid: parsed.Link != ""}
+		params.Description = sql.NullString{String: parsed.Description, Valid: parsed.Description != ""}
+	}
 
 	// create a feed follow record for the current user when they add a feed
-	feed, err := state.db.CreateFeed(context.Background(), params)
+	newFeed, err := state.db.CreateFeed(ctx, params)
 	if err != nil {
 		return fmt.Errorf("error creating feed: %v", err)
 	}
 
 	// create a feed follow record for the current user
-	_, err = state.db.CreateFeedFollow(context.Background(), database.CreateFeedFollowParams{
+	_, err = state.db.CreateFeedFollow(ctx, database.CreateFeedFollowParams{
 		ID:        uuid.New(),
 		CreatedAt: time.Now(),
 		UpdatedAt: time.Now(),
 		UserID:    user.ID,
-		FeedID:    feed.ID,
+		FeedID:    newFeed.ID,
 	})
 	if err != nil {
 		return fmt.Errorf("error creating feed follow: %v", err)
 	}
 
-	fmt.Printf("Feed follow created for user %s to feed %s\n", user.Name, feed.Name)
+	fmt.Printf("Feed follow created for user %s to feed %s\n", user.Name, newFeed.Name)
+
+	fmt.Printf("Feed created: %+v\n", newFeed)
 
-	fmt.Printf("Feed created: %+v\n", feed)
+	// ingest the items we already fetched so browse shows content right away
+	if parsed != nil {
+		if err := storeItems(ctx, state, newFeed, parsed.Items); err != nil {
+			return err
+		}
+		fmt.Printf("Stored %d posts from %s\n", len(parsed.Items), newFeed.Name)
+
+		if err := storeDocumentInfo(ctx, state, newFeed, parsed); err != nil {
+			return err
+		}
+	}
 	return nil
 }
 
@@ -405,6 +426,17 @@ func handlerFollow(s *state, cmd command, user database.User) error {
 	feedURL := cmd.Args[0]
 
 	feed, err := s.db.GetFeedByURL(ctx, feedURL)
+	if err == sql.ErrNoRows {
+		// the user may have pasted the site's homepage rather than its feed
+		discovered, _, discoverErr := discoverFeed(ctx, s, feedURL)
+		if discoverErr != nil {
+			return fmt.Errorf("feed not found: %s (%v)", feedURL, discoverErr)
+		}
+		feed, err = s.db.GetFeedByURL(ctx, discovered)
+		if err == sql.ErrNoRows {
+			return fmt.Errorf("feed not found: %s, add it with addfeed", discovered)
+		}
+	}
 	if err != nil {
 		return fmt.Errorf("error fetching feed by URL: %v", err)
 	}
@@ -483,99 +515,651 @@ func handlerUnfollow(s *state, cmd command, user database.User) error {
 	return nil
 }
 
-// create an agg function labeled scrapeFeeds. it should get the next feed to fetch using GetNextFeedToFetch, mark it as fetched using MarkFeedFetched, fetch the feed using fetchFeed, iterate over the items and save items to posts database using CreatePost. make sure to skip duplicate posts based on the feed URL and post title
+// create an agg function labeled scrapeFeeds. it should claim the next feed to fetch using ClaimNextFeed, fetch the feed using fetchFeed, iterate over the items and save items to posts database using CreatePost. posts are identified by feed and guid, so updated items overwrite their earlier version
 func scrapeFeeds(s *state) error {
 	ctx := context.Background()
 
-	feed, err := s.db.GetNextFeedToFetch(ctx)
+	// claim the feed that has been due the longest and lease it in one statement
+	feed, err := claimNextFeed(ctx, s)
+	if err == sql.ErrNoRows {
+		return fmt.Errorf("no feeds are due for fetching")
+	}
 	if err != nil {
-		return fmt.Errorf("error getting next feed to fetch: %v", err)
+		return fmt.Errorf("error claiming next feed to fetch: %v", err)
 	}
 
-	if err := s.db.MarkFeedFetched(ctx, feed.ID); err != nil {
-		return fmt.Errorf("error marking feed as fetched: %v", err)
+	return scrapeFeed(ctx, s, feed)
+}
+
+// scrapeFeed fetches a single feed that has already been claimed and stores its items as posts. failures are recorded against the feed and back off its next fetch
+func scrapeFeed(ctx context.Context, s *state, feed database.Feed) error {
+	err := fetchAndStoreFeed(ctx, s, feed)
+	if err == nil {
+		return nil
 	}
 
-	rssFeed, err := fetchFeed(ctx, feed.Url)
+	failures := int(feed.ConsecutiveFailures) + 1
+	retryAt := time.Now().Add(s.policy.Backoff(failures))
+	recordErr := s.db.RecordFeedFailure(ctx, database.RecordFeedFailureParams{
+		ID:          feed.ID,
+		LastError:   sql.NullString{String: err.Error(), Valid: true},
+		NextFetchAt: sql.NullTime{Time: retryAt, Valid: true},
+	})
+	if recordErr != nil {
+		return fmt.Errorf("%v (and error recording failure: %v)", err, recordErr)
+	}
+
+	// a feed that is gone for good, or has been failing for too long, stops taking scraper slots
+	threshold := s.cfg.DeadFeedFailures
+	if threshold <= 0 {
+		threshold = config.DefaultDeadFeedFailures
+	}
+	var statusErr *httpStatusError
+	if (errors.As(err, &statusErr) && statusErr.StatusCode == http.StatusGone) || failures >= threshold {
+		if deadErr := s.db.SetFeedStatus(ctx, database.SetFeedStatusParams{ID: feed.ID, Status: feedStatusDead}); deadErr != nil {
+			return fmt.Errorf("%v (and error marking feed dead: %v)", err, deadErr)
+		}
+		return fmt.Errorf("%v (failure %d, feed marked dead)", err, failures)
+	}
+
+	return fmt.Errorf("%v (failure %d, retrying at %s)", err, failures, retryAt.Format(time.RFC3339))
+}
+
+// fetchAndStoreFeed fetches a feed, stores its items as posts and schedules its next fetch
+func fetchAndStoreFeed(ctx context.Context, s *state, feed database.Feed) error {
+	result, err := s.fetcher.fetchFeed(ctx, feed.Url, feed.Etag.String, feed.LastModified.String)
 	if err != nil {
 		return fmt.Errorf("error fetching feed: %v", err)
 	}
 
-	for _, item := range rssFeed.Channel.Item {
-		pubDate, _ := time.Parse(time.RFC1123Z, item.PubDate)
+	// the publisher moved the feed for good, so stop requesting the old URL
+	if result.MovedTo != "" && result.MovedTo != feed.Url {
+		feed, err = moveFeed(ctx, s, feed, result.MovedTo)
+		if err != nil {
+			return err
+		}
+	}
+
+	// 304 Not Modified: nothing new since the last fetch, so keep the interval chosen last time
+	if result.NotModified {
+		interval := s.policy.Clamp(time.Duration(feed.FetchIntervalSeconds.Int32) * time.Second)
+		return scheduleNextFetch(ctx, s, feed.ID, interval, nil)
+	}
+	parsed := result.Feed
+
+	if err := storeItems(ctx, s, feed, parsed.Items); err != nil {
+		return err
+	}
+	if parsed.Truncated {
+		log.Printf("%s: stopped reading after %d items", feed.Url, len(parsed.Items))
+	}
+
+	// only remember the caching headers once every item is stored, otherwise a failed run would be skipped by the next 304
+	err = s.db.UpdateFeedCacheHeaders(ctx, database.UpdateFeedCacheHeadersParams{
+		ID:           feed.ID,
+		Etag:         sql.NullString{String: result.ETag, Valid: result.ETag != ""},
+		LastModified: sql.NullString{String: result.LastModified, Valid: result.LastModified != ""},
+	})
+	if err != nil {
+		return fmt.Errorf("error updating feed cache headers: %v", err)
+	}
+
+	if err := storeDocumentInfo(ctx, s, feed, parsed); err != nil {
+		return err
+	}
+
+	// schedule the next fetch from the feed's posting frequency and polling hints
+	return scheduleNextFetch(ctx, s, feed.ID, s.policy.Interval(parsed), parsed)
+}
+
+// storeDocumentInfo records what parsing the feed's document revealed: the encoding it is published in and the problems that had to be worked around. both show in feeds:status. the columns are only written when they change
+func storeDocumentInfo(ctx context.Context, s *state, dbFeed database.Feed, parsed *feed.Feed) error {
+	if parsed.Encoding != dbFeed.Encoding.String {
+		err := s.db.SetFeedEncoding(ctx, database.SetFeedEncodingParams{
+			ID:       dbFeed.ID,
+			Encoding: sql.NullString{String: parsed.Encoding, Valid: parsed.Encoding != ""},
+		})
+		if err != nil {
+			return fmt.Errorf("error updating feed encoding: %v", err)
+		}
+	}
+
+	warnings := strings.Join(parsed.Warnings, "; ")
+	if warnings != dbFeed.ParseWarnings.String {
+		if warnings != "" {
+			log.Printf("%s: %s", dbFeed.Url, warnings)
+		}
+		err := s.db.SetFeedParseWarnings(ctx, database.SetFeedParseWarningsParams{
+			ID:            dbFeed.ID,
+			ParseWarnings: sql.NullString{String: warnings, Valid: warnings != ""},
+		})
+		if err != nil {
+			return fmt.Errorf("error updating feed parse warnings: %v", err)
+		}
+	}
+	return nil
+}
+
+// storeItems saves feed items as posts of the given feed. posts are identified by feed and guid, so updated items overwrite their earlier version. for feeds in full content mode, new posts are marked for fetchArticles to download their article
+func storeItems(ctx context.Context, s *state, dbFeed database.Feed, items []feed.Item) error {
+	// items without a usable date are stamped with the fetch time rather than the zero time, so they do not sink to the bottom of browse
+	fetchedAt := time.Now()
 
+	// posts deleted by prune stay deleted, even while the feed still lists them
+	guids := make([]string, len(items))
+	for i, item := range items {
+		guids[i] = item.GUID
+	}
+	pruned, err := s.db.GetPrunedGUIDs(ctx, database.GetPrunedGUIDsParams{FeedID: dbFeed.ID, Guids: guids})
+	if err != nil {
+		return fmt.Errorf("error fetching pruned posts: %v", err)
+	}
+
+	for _, item := range items {
+		if slices.Contains(pruned, item.GUID) {
+			continue
+		}
+		publishedAt := item.Published
+		estimated := publishedAt.IsZero()
+		if estimated {
+			publishedAt = fetchedAt
+		}
+
+		authors := formatAuthors(item.Authors)
+		// only an allowlisted subset of the HTML is stored, so it stays safe to show in a web page
+		summary := htmlutil.Sanitize(item.Summary)
+		content := htmlutil.Sanitize(item.Content)
 		params := database.CreatePostParams{
 			ID:        uuid.New(),
 			CreatedAt: time.Now(),
 			UpdatedAt: time.Now(),
-			FeedID:    feed.ID,
+			FeedID:    dbFeed.ID,
+			Guid:      item.GUID,
 			Title:     item.Title,
 			Url:       item.Link,
-			Description: sql.NullString{
-				String: item.Description,
-				Valid:  item.Description != "",
-			},
-			PublishedAt: pubDate,
+			// the description column holds the short summary, the content column the full body
+			Description:          sql.NullString{String: summary, Valid: summary != ""},
+			Content:              sql.NullString{String: content, Valid: content != ""},
+			Authors:              sql.NullString{String: authors, Valid: authors != ""},
+			CommentsUrl:          sql.NullString{String: item.Comments, Valid: item.Comments != ""},
+			Episode:              sql.NullInt32{Int32: int32(item.Episode), Valid: item.Episode > 0},
+			PublishedAt:          publishedAt,
+			PublishedAtEstimated: estimated,
+			FullContentPending:   dbFeed.FetchFullContent && item.Link != "",
 		}
+		if err := storePost(ctx, s, params, item); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
 
-		_, err := s.db.CreatePost(ctx, params)
+// storeFullContent downloads the article a post links to and stores its main readable content, so read can show the whole article offline
+func storeFullContent(ctx context.Context, s *state, postID uuid.UUID, link string) error {
+	page, pageURL, err := s.fetcher.fetchArticle(ctx, link)
+	if err != nil {
+		return err
+	}
+	article := htmlutil.ExtractArticle(page, pageURL)
+	if article == "" {
+		return fmt.Errorf("no article content found")
+	}
+	err = s.db.SetPostFullContent(ctx, database.SetPostFullContentParams{
+		ID:          postID,
+		FullContent: sql.NullString{String: article, Valid: true},
+	})
+	if err != nil {
+		return fmt.Errorf("error storing full content: %v", err)
+	}
+	return nil
+}
+
+// storePost upserts a single post together with the categories and enclosures of its item, in one transaction
+func storePost(ctx context.Context, s *state, params database.CreatePostParams, item feed.Item) error {
+	tx, err := s.dbSQL.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("error starting transaction: %v", err)
+	}
+	defer tx.Rollback()
+	qtx := s.db.WithTx(tx)
+
+	// CreatePost upserts on (feed_id, guid) and returns no row when the stored post is already up to date
+	post, err := qtx.CreatePost(ctx, params)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil
+		}
+		return fmt.Errorf("error creating post: %v", err)
+	}
+
+	// the post is new or changed, so replace its categories and enclosures with the current ones
+	if err := qtx.DeletePostCategories(ctx, post.ID); err != nil {
+		return fmt.Errorf("error clearing post categories: %v", err)
+	}
+	if len(item.Categories) > 0 {
+		err = qtx.AddPostCategories(ctx, database.AddPostCategoriesParams{PostID: post.ID, Categories: item.Categories})
+		if err != nil {
+			return fmt.Errorf("error storing post categories: %v", err)
+		}
+	}
+
+	if err := qtx.DeletePostEnclosures(ctx, post.ID); err != nil {
+		return fmt.Errorf("error clearing post enclosures: %v", err)
+	}
+	for i, e := range item.Enclosures {
+		seconds := int32(e.Duration / time.Second)
+		err = qtx.CreatePostEnclosure(ctx, database.CreatePostEnclosureParams{
+			PostID:          post.ID,
+			Position:        int32(i + 1),
+			Url:             e.URL,
+			MimeType:        sql.NullString{String: e.Type, Valid: e.Type != ""},
+			Length:          sql.NullInt64{Int64: e.Length, Valid: e.Length > 0},
+			DurationSeconds: sql.NullInt32{Int32: seconds, Valid: seconds > 0},
+		})
 		if err != nil {
-			if pgErr, ok := err.(*pq.Error); ok && pgErr.Code == "23505" {
-				continue
-			}
-			return fmt.Errorf("error creating post: %v", err)
+			return fmt.Errorf("error storing post enclosure: %v", err)
 		}
 	}
 
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("error committing post: %v", err)
+	}
 	return nil
+}
 
+// enclosureDetails describes the type, size and playing time of an enclosure, e.g. " (audio/mpeg, 34.2 MB, 1:02:03)", leaving out what the feed did not give
+func enclosureDetails(e database.PostEnclosure) string {
+	var details []string
+	if e.MimeType.Valid {
+		details = append(details, e.MimeType.String)
+	}
+	if e.Length.Valid {
+		details = append(details, formatBytes(e.Length.Int64))
+	}
+	if e.DurationSeconds.Valid {
+		details = append(details, formatDuration(time.Duration(e.DurationSeconds.Int32)*time.Second))
+	}
+	if len(details) == 0 {
+		return ""
+	}
+	return " (" + strings.Join(details, ", ") + ")"
 }
 
+// formatAuthors renders authors as a comma separated list, e.g. "Jane Doe (jane@example.com), John Roe"
+func formatAuthors(people []feed.Person) string {
+	var names []string
+	for _, p := range people {
+		switch {
+		case p.Name != "" && p.Email != "":
+			names = append(names, fmt.Sprintf("%s (%s)", p.Name, p.Email))
+		case p.Name != "":
+			names = append(names, p.Name)
+		case p.Email != "":
+			names = append(names, p.Email)
+		case p.URL != "":
+			names = append(names, p.URL)
+		}
+	}
+	return strings.Join(names, ", ")
+}
+
+// moveFeed points a feed at the URL it was permanently redirected to. if another feed already uses that URL, the two are merged: follows and posts move to the existing feed and the old one is deleted. it returns the feed to keep scraping into
+func moveFeed(ctx context.Context, s *state, feed database.Feed, newURL string) (database.Feed, error) {
+	existing, err := s.db.GetFeedByURL(ctx, newURL)
+	if err == sql.ErrNoRows {
+		if err := s.db.UpdateFeedURL(ctx, database.UpdateFeedURLParams{ID: feed.ID, Url: newURL}); err != nil {
+			return feed, fmt.Errorf("error updating feed URL: %v", err)
+		}
+		log.Printf("Feed %s moved permanently from %s to %s", feed.Name, feed.Url, newURL)
+		feed.Url = newURL
+		return feed, nil
+	}
+	if err != nil {
+		return feed, fmt.Errorf("error fetching feed by URL: %v", err)
+	}
+
+	tx, err := s.dbSQL.BeginTx(ctx, nil)
+	if err != nil {
+		return feed, fmt.Errorf("error starting transaction: %v", err)
+	}
+	defer tx.Rollback()
+	qtx := s.db.WithTx(tx)
+
+	if err := qtx.MoveFeedFollows(ctx, database.MoveFeedFollowsParams{ToFeedID: existing.ID, FromFeedID: feed.ID}); err != nil {
+		return feed, fmt.Errorf("error moving feed follows: %v", err)
+	}
+	if err := qtx.MovePosts(ctx, database.MovePostsParams{ToFeedID: existing.ID, FromFeedID: feed.ID}); err != nil {
+		return feed, fmt.Errorf("error moving posts: %v", err)
+	}
+	if err := qtx.MovePrunedPosts(ctx, database.MovePrunedPostsParams{ToFeedID: existing.ID, FromFeedID: feed.ID}); err != nil {
+		return feed, fmt.Errorf("error moving pruned posts: %v", err)
+	}
+	// follows and posts the target already had are removed along with the old feed
+	if err := qtx.DeleteFeed(ctx, feed.ID); err != nil {
+		return feed, fmt.Errorf("error deleting moved feed: %v", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return feed, fmt.Errorf("error committing feed merge: %v", err)
+	}
+
+	log.Printf("Feed %s moved permanently from %s to %s, merged into existing feed %s", feed.Name, feed.Url, newURL, existing.Name)
+
+	// keep scraping into the surviving feed, but with its own cache validators
+	feed.ID = existing.ID
+	feed.Name = existing.Name
+	feed.Url = existing.Url
+	feed.Etag = sql.NullString{}
+	feed.LastModified = sql.NullString{}
+	return feed, nil
+}
+
+// feed states stored in feeds.status. only active feeds are scheduled
+const (
+	feedStatusActive = "active"
+	feedStatusPaused = "paused"
+	feedStatusDead   = "dead"
+)
+
+// feeds:pause takes a feed URL and stops fetching it until it is resumed
+func handlerFeedsPause(s *state, cmd command) error {
+	if len(cmd.Args) < 1 {
+		return fmt.Errorf("feed URL argument is required")
+	}
+
+	ctx := context.Background()
+	feed, err := s.db.GetFeedByURL(ctx, cmd.Args[0])
+	if err != nil {
+		return fmt.Errorf("error fetching feed by URL: %v", err)
+	}
+
+	err = s.db.SetFeedStatus(ctx, database.SetFeedStatusParams{ID: feed.ID, Status: feedStatusPaused})
+	if err != nil {
+		return fmt.Errorf("error pausing feed: %v", err)
+	}
+
+	fmt.Printf("Paused feed %s\n", feed.Name)
+	return nil
+}
+
+// feeds:resume takes a feed URL and reactivates a paused or dead feed. it is fetched on the next aggregator round
+func handlerFeedsResume(s *state, cmd command) error {
+	if len(cmd.Args) < 1 {
+		return fmt.Errorf("feed URL argument is required")
+	}
+
+	ctx := context.Background()
+	feed, err := s.db.GetFeedByURL(ctx, cmd.Args[0])
+	if err != nil {
+		return fmt.Errorf("error fetching feed by URL: %v", err)
+	}
+
+	if err := s.db.ResumeFeed(ctx, feed.ID); err != nil {
+		return fmt.Errorf("error resuming feed: %v", err)
+	}
+
+	fmt.Printf("Resumed feed %s\n", feed.Name)
+	return nil
+}
+
+// feeds:full-content takes a feed URL and on or off. when on, the article each new post links to is downloaded and its readable content stored for read, for feeds that only publish a summary
+func handlerFeedsFullContent(s *state, cmd command) error {
+	if len(cmd.Args) != 2 || cmd.Args[1] != "on" && cmd.Args[1] != "off" {
+		return fmt.Errorf("usage: feeds:full-content <url> on|off")
+	}
+
+	ctx := context.Background()
+	feed, err := s.db.GetFeedByURL(ctx, cmd.Args[0])
+	if err != nil {
+		return fmt.Errorf("error fetching feed by URL: %v", err)
+	}
+
+	on := cmd.Args[1] == "on"
+	err = s.db.SetFeedFetchFullContent(ctx, database.SetFeedFetchFullContentParams{ID: feed.ID, FetchFullContent: on})
+	if err != nil {
+		return fmt.Errorf("error updating feed: %v", err)
+	}
+
+	if on {
+		fmt.Printf("Fetching full articles for new posts of %s\n", feed.Name)
+	} else {
+		fmt.Printf("Stopped fetching full articles for %s\n", feed.Name)
+	}
+	return nil
+}
+
+// prune deletes the posts published more than --days days ago, 30 by default, to keep the database small. starred posts are never deleted. the guids of deleted posts are kept, so fetching a feed that still lists them does not bring them back. --unfollowed also deletes the feeds nobody follows any more with all their posts; their starred posts live on as the copies kept with the stars
+func handlerPrune(s *state, cmd command) error {
+	fs := flag.NewFlagSet("prune", flag.ContinueOnError)
+	days := fs.Int("days", 30, "delete posts published more than this many days ago")
+	unfollowed := fs.Bool("unfollowed", false, "also delete the feeds nobody follows")
+	args, err := parseFlags(fs, cmd.Args)
+	if err != nil {
+		return err
+	}
+	if len(args) > 0 || *days < 1 {
+		return fmt.Errorf("usage: prune [--days N] [--unfollowed]")
+	}
+
+	ctx := context.Background()
+	if *unfollowed {
+		feeds, err := s.db.DeleteUnfollowedFeeds(ctx)
+		if err != nil {
+			return fmt.Errorf("error deleting unfollowed feeds: %v", err)
+		}
+		fmt.Printf("Deleted %d unfollowed feeds\n", feeds)
+	}
+
+	cutoff := time.Now().AddDate(0, 0, -*days)
+	posts, err := s.db.DeleteOldPosts(ctx, cutoff)
+	if err != nil {
+		return fmt.Errorf("error deleting old posts: %v", err)
+	}
+	fmt.Printf("Deleted %d posts published before %s\n", posts, cutoff.Format(time.DateOnly))
+	return nil
+}
+
+// feeds:dead lists the feeds that were deactivated, with the error that killed them
+func handlerFeedsDead(s *state, cmd command) error {
+	feeds, err := s.db.GetFeedsByStatus(context.Background(), feedStatusDead)
+	if err != nil {
+		return fmt.Errorf("error fetching dead feeds: %v", err)
+	}
+
+	for _, feed := range feeds {
+		fmt.Printf("* Name: %s, URL: %s, Failures: %d\n", feed.Name, feed.Url, feed.ConsecutiveFailures)
+		if feed.LastError.Valid {
+			fmt.Printf("    last error at %v: %s\n", feed.LastErrorAt.Time, feed.LastError.String)
+		}
+	}
+	return nil
+}
+
+// feeds:status lists every feed with its fetch schedule and, for failing feeds, how often and why they failed
 func handlerFeedsStatus(s *state, cmd command) error {
-	rows, err := s.dbSQL.Query(`SELECT id, name, last_fetched_at, updated_at FROM feeds ORDER BY last_fetched_at NULLS FIRST, updated_at ASC`)
+	rows, err := s.dbSQL.Query(`SELECT id, name, status, last_fetched_at, updated_at, next_fetch_at, consecutive_failures, last_error, last_error_at, encoding, parse_warnings FROM feeds ORDER BY consecutive_failures DESC, last_fetched_at NULLS FIRST, updated_at ASC`)
 	if err != nil {
 		return err
 	}
 	defer rows.Close()
 	for rows.Next() {
-		var id, name string
-		var last, upd sql.NullTime
-		if err := rows.Scan(&id, &name, &last, &upd); err != nil {
+		var id, name, status string
+		var last, upd, next, errAt sql.NullTime
+		var failures int
+		var lastErr, encoding, warnings sql.NullString
+		if err := rows.Scan(&id, &name, &status, &last, &upd, &next, &failures, &lastErr, &errAt, &encoding, &warnings); err != nil {
 			return err
 		}
-		fmt.Printf("* %s | %s | last=%v | upd=%v\n", id, name, last.Time, upd.Time)
+		fmt.Printf("* %s | %s | %s | last=%v | upd=%v | next=%v | failures=%d | encoding=%s\n", id, name, status, last.Time, upd.Time, next.Time, failures, encoding.String)
+		if lastErr.Valid {
+			fmt.Printf("    last error at %v: %s\n", errAt.Time, lastErr.String)
+		}
+		if warnings.Valid {
+			fmt.Printf("    warnings: %s\n", warnings.String)
+		}
 	}
 	return rows.Err()
 }
 
-// browse command that takes an optional limit parameter if not provided it defaults to 2. Print the posts in the terminal
+// browse command that takes an optional limit parameter if not provided it defaults to 2. Print the posts in the terminal with their id, authors, categories, comments link and enclosures. --full prints the full content instead of the summary. the HTML of posts is rendered as text wrapped to the terminal width, with links listed below it. only unread posts are shown unless --all is given
 func handlerBrowse(s *state, cmd command, user database.User) error {
-	limit := 2
-	var err error
+	fs := flag.NewFlagSet("browse", flag.ContinueOnError)
+	full := fs.Bool("full", false, "show the full content of posts instead of their summary")
+	all := fs.Bool("all", false, "include posts you have already read")
+	args, err := parseFlags(fs, cmd.Args)
+	if err != nil {
+		return err
+	}
 
-	if len(cmd.Args) > 0 {
-		limit, err = strconv.Atoi(cmd.Args[0])
+	limit := 2
+	if len(args) > 0 {
+		limit, err = strconv.Atoi(args[0])
 		if err != nil {
 			return fmt.Errorf("invalid limit: %v", err)
 		}
 	}
 
 	posts, err := s.db.GetPostsForUser(context.Background(), database.GetPostsForUserParams{
-		UserID: user.ID,
-		Limit:  int32(limit),
+		UserID:      user.ID,
+		IncludeRead: *all,
+		Limit:       int32(limit),
 	})
 	if err != nil {
 		return fmt.Errorf("error fetching posts: %v", err)
 	}
+	if len(posts) == 0 && !*all {
+		fmt.Println("No unread posts. Use browse --all to see the ones you have read")
+		return nil
+	}
+
+	// load the enclosures of the whole page in one query
+	postIDs := make([]uuid.UUID, len(posts))
+	for i, post := range posts {
+		postIDs[i] = post.ID
+	}
+	enclosureRows, err := s.db.GetEnclosuresForPosts(context.Background(), postIDs)
+	if err != nil {
+		return fmt.Errorf("error fetching enclosures: %v", err)
+	}
+	enclosures := make(map[uuid.UUID][]database.PostEnclosure)
+	for _, e := range enclosureRows {
+		enclosures[e.PostID] = append(enclosures[e.PostID], e)
+	}
 
+	width := terminalWidth()
 	for _, post := range posts {
-		fmt.Printf("* %s\n%s\nPublished at: %s\n---\n",
-			post.Title, post.Description.String, post.PublishedAt.Format(time.RFC3339))
+		published := post.PublishedAt.Format(time.RFC3339)
+		if post.PublishedAtEstimated {
+			published += " (estimated)"
+		}
+
+		// show the summary, or the full body when asked for or when the feed publishes no summary
+		body := post.Description.String
+		if *full && post.Content.Valid || body == "" {
+			body = post.Content.String
+		}
+
+		title := post.Title
+		if post.Read {
+			title += " (read)"
+		}
+		fmt.Printf("* %s\n", title)
+		fmt.Printf("ID: %s\n", post.ID)
+		if post.Episode.Valid {
+			fmt.Printf("Episode: %d\n", post.Episode.Int32)
+		}
+		if post.Authors.Valid {
+			fmt.Printf("By: %s\n", post.Authors.String)
+		}
+		if post.Categories != "" {
+			fmt.Printf("Categories: %s\n", post.Categories)
+		}
+		if body != "" {
+			fmt.Println(htmlutil.ToText(body, width))
+		}
+		fmt.Printf("Published at: %s\n", published)
+		if post.CommentsUrl.Valid {
+			fmt.Printf("Comments: %s\n", post.CommentsUrl.String)
+		}
+		for _, e := range enclosures[post.ID] {
+			fmt.Printf("Enclosure %d: %s%s\n", e.Position, e.Url, enclosureDetails(e))
+		}
+		fmt.Println("---")
 	}
 
 	return nil
 }
 
+// terminalWidth returns the width to wrap text at: the width of the terminal stdout is attached to, else $COLUMNS when the shell exports it, else 80
+func terminalWidth() int {
+	if n, _, err := term.GetSize(int(os.Stdout.Fd())); err == nil && n > 0 {
+		return n
+	}
+	if n, err := strconv.Atoi(os.Getenv("COLUMNS")); err == nil && n > 0 {
+		return n
+	}
+	return 80
+}
+
+// read takes a post id and prints the whole post: the article downloaded in full content mode, or else the content or summary from the feed. the post is marked as read
+func handlerRead(s *state, cmd command, user database.User) error {
+	if len(cmd.Args) != 1 {
+		return fmt.Errorf("usage: read <post-id>")
+	}
+	postID, err := uuid.Parse(cmd.Args[0])
+	if err != nil {
+		return fmt.Errorf("invalid post id: %s", cmd.Args[0])
+	}
+
+	post, err := s.db.GetPostForUser(context.Background(), database.GetPostForUserParams{ID: postID, UserID: user.ID})
+	if err == sql.ErrNoRows {
+		// a starred post outlives its feed as the copy kept with the star
+		saved, err := s.db.GetSavedPost(context.Background(), database.GetSavedPostParams{UserID: user.ID, ID: postID})
+		if err == sql.ErrNoRows {
+			return fmt.Errorf("post %s not found", postID)
+		}
+		if err != nil {
+			return fmt.Errorf("error fetching post: %v", err)
+		}
+		fmt.Printf("* %s\n", saved.Title)
+		fmt.Printf("Feed: %s\n", saved.FeedName)
+		fmt.Printf("Published at: %s\n", saved.PublishedAt.Format(time.RFC3339))
+		fmt.Printf("Link: %s\n\n", saved.Url)
+		fmt.Println(htmlutil.ToText(saved.Content.String, terminalWidth()))
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("error fetching post: %v", err)
+	}
+
+	body := post.FullContent.String
+	if body == "" {
+		body = post.Content.String
+	}
+	if body == "" {
+		body = post.Description.String
+	}
+
+	published := post.PublishedAt.Format(time.RFC3339)
+	if post.PublishedAtEstimated {
+		published += " (estimated)"
+	}
+
+	fmt.Printf("* %s\n", post.Title)
+	fmt.Printf("Feed: %s\n", post.FeedName)
+	if post.Authors.Valid {
+		fmt.Printf("By: %s\n", post.Authors.String)
+	}
+	fmt.Printf("Published at: %s\n", published)
+	fmt.Printf("Link: %s\n\n", post.Url)
+	fmt.Println(htmlutil.ToText(body, terminalWidth()))
+
+	if _, err := s.db.MarkPostRead(context.Background(), database.MarkPostReadParams{UserID: user.ID, ID: post.ID}); err != nil {
+		return fmt.Errorf("error marking post read: %v", err)
+	}
+	return nil
+}
+
 func main() {
 
 	// read config file
@@ -584,9 +1168,17 @@ func main() {
 		log.Fatalf("Error reading config: %v", err)
 	}
 
+	// build the HTTP clients once so every fetch shares their connection pools
+	fetcher, err := newFetcher(cfg.FetcherOrDefault())
+	if err != nil {
+		log.Fatalf("Error configuring fetcher: %v", err)
+	}
+
 	// store config file in a new instance of state struct
 	s := &state{
-		cfg: cfg,
+		cfg:     cfg,
+		policy:  schedule.DefaultPolicy,
+		fetcher: fetcher,
 	}
 
 	// create new instance of commands struct with map of handler functions
@@ -638,9 +1230,31 @@ func main() {
 	// register feedsStatus command
 	cmds.register("feeds:status", handlerFeedsStatus)
 
+	// register feed state commands
+	cmds.register("feeds:pause", handlerFeedsPause)
+	cmds.register("feeds:resume", handlerFeedsResume)
+	cmds.register("feeds:dead", handlerFeedsDead)
+	cmds.register("feeds:full-content", handlerFeedsFullContent)
+
+	// register prune command
+	cmds.register("prune", handlerPrune)
+
 	// register browse command
 	cmds.register("browse", middlewareLoggedIn(handlerBrowse))
 
+	// register read state commands
+	cmds.register("read", middlewareLoggedIn(handlerRead))
+	cmds.register("mark-read", middlewareLoggedIn(handlerMarkRead))
+	cmds.register("mark-unread", middlewareLoggedIn(handlerMarkUnread))
+
+	// register starred post commands
+	cmds.register("star", middlewareLoggedIn(handlerStar))
+	cmds.register("unstar", middlewareLoggedIn(handlerUnstar))
+	cmds.register("starred", middlewareLoggedIn(handlerStarred))
+
+	// register download command
+	cmds.register("download", handlerDownload)
+
 	// load database URL to the config struct and open a connection to dbURL using sql.Open
 
 	db, err := sql.Open("postgres", cfg.DBUrl)
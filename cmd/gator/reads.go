@@ -0,0 +1,136 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"flag"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/jamesBoder/rss_aggreggator/internal/database"
+)
+
+// readFilter selects the posts mark-read and mark-unread apply to: the posts given by id, or else every post of the feeds the user follows, narrowed down by feed and publication date
+type readFilter struct {
+	postIDs []uuid.UUID
+	feedID  uuid.NullUUID
+	before  sql.NullTime
+}
+
+// parseReadFilter reads the arguments shared by mark-read and mark-unread: [--feed URL] [--before DATE] [post-id...]
+func parseReadFilter(ctx context.Context, s *state, name string, args []string) (readFilter, error) {
+	var filter readFilter
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	feedURL := fs.String("feed", "", "only posts of the feed with this URL")
+	before := fs.String("before", "", "only posts published before this date, as 2006-01-02 or RFC 3339")
+	ids, err := parseFlags(fs, args)
+	if err != nil {
+		return filter, err
+	}
+	if len(ids) > 0 && (*feedURL != "" || *before != "") {
+		return filter, fmt.Errorf("usage: %s [--feed URL] [--before DATE] | %s <post-id>...", name, name)
+	}
+
+	for _, arg := range ids {
+		id, err := uuid.Parse(arg)
+		if err != nil {
+			return filter, fmt.Errorf("invalid post id: %s", arg)
+		}
+		filter.postIDs = append(filter.postIDs, id)
+	}
+
+	if *feedURL != "" {
+		feed, err := s.db.GetFeedByURL(ctx, *feedURL)
+		if err != nil {
+			return filter, fmt.Errorf("error fetching feed by URL: %v", err)
+		}
+		filter.feedID = uuid.NullUUID{UUID: feed.ID, Valid: true}
+	}
+
+	if *before != "" {
+		t, err := parseDate(*before)
+		if err != nil {
+			return filter, err
+		}
+		filter.before = sql.NullTime{Time: t, Valid: true}
+	}
+	return filter, nil
+}
+
+// parseDate accepts a day, taken as midnight local time, or a full RFC 3339 timestamp
+func parseDate(s string) (time.Time, error) {
+	if t, err := time.ParseInLocation("2006-01-02", s, time.Local); err == nil {
+		return t, nil
+	}
+	t, err := time.Parse(time.RFC3339, s)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid date %q, use 2006-01-02 or 2006-01-02T15:04:05Z07:00", s)
+	}
+	return t, nil
+}
+
+// mark-read marks posts as read so browse stops showing them: the posts given by id, or else every post of the feeds the user follows. --feed limits it to one feed and --before to posts published before a date
+func handlerMarkRead(s *state, cmd command, user database.User) error {
+	ctx := context.Background()
+	filter, err := parseReadFilter(ctx, s, "mark-read", cmd.Args)
+	if err != nil {
+		return err
+	}
+
+	var marked int64
+	if len(filter.postIDs) > 0 {
+		for _, id := range filter.postIDs {
+			n, err := s.db.MarkPostRead(ctx, database.MarkPostReadParams{UserID: user.ID, ID: id})
+			if err != nil {
+				return fmt.Errorf("error marking post read: %v", err)
+			}
+			marked += n
+		}
+	} else {
+		marked, err = s.db.MarkPostsRead(ctx, database.MarkPostsReadParams{
+			UserID: user.ID,
+			FeedID: filter.feedID,
+			Before: filter.before,
+		})
+		if err != nil {
+			return fmt.Errorf("error marking posts read: %v", err)
+		}
+	}
+
+	fmt.Printf("Marked %d posts as read\n", marked)
+	return nil
+}
+
+// mark-unread takes the same arguments as mark-read and brings the posts back into browse
+func handlerMarkUnread(s *state, cmd command, user database.User) error {
+	ctx := context.Background()
+	filter, err := parseReadFilter(ctx, s, "mark-unread", cmd.Args)
+	if err != nil {
+		return err
+	}
+
+	var marked int64
+	if len(filter.postIDs) > 0 {
+		for _, id := range filter.postIDs {
+			n, err := s.db.MarkPostUnread(ctx, database.MarkPostUnreadParams{UserID: user.ID, PostID: id})
+			if err != nil {
+				return fmt.Errorf("error marking post unread: %v", err)
+			}
+			marked += n
+		}
+	} else {
+		marked, err = s.db.MarkPostsUnread(ctx, database.MarkPostsUnreadParams{
+			UserID: user.ID,
+			FeedID: filter.feedID,
+			Before: filter.before,
+		})
+		if err != nil {
+			return fmt.Errorf("error marking posts unread: %v", err)
+		}
+	}
+
+	fmt.Printf("Marked %d posts as unread\n", marked)
+	return nil
+}
@@ -0,0 +1,98 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"flag"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/jamesBoder/rss_aggreggator/internal/database"
+)
+
+// star takes a post id and keeps the post for later, with an optional --note. a copy of the post is saved with the star, so prune and deleting the feed do not take it away. starring a post again updates its note
+func handlerStar(s *state, cmd command, user database.User) error {
+	fs := flag.NewFlagSet("star", flag.ContinueOnError)
+	note := fs.String("note", "", "a note to keep with the post")
+	args, err := parseFlags(fs, cmd.Args)
+	if err != nil {
+		return err
+	}
+	if len(args) != 1 {
+		return fmt.Errorf("usage: star <post-id> [--note TEXT]")
+	}
+	postID, err := uuid.Parse(args[0])
+	if err != nil {
+		return fmt.Errorf("invalid post id: %s", args[0])
+	}
+
+	saved, err := s.db.StarPost(context.Background(), database.StarPostParams{
+		ID:     uuid.New(),
+		Notes:  sql.NullString{String: *note, Valid: *note != ""},
+		PostID: postID,
+		UserID: user.ID,
+	})
+	if err == sql.ErrNoRows {
+		return fmt.Errorf("post %s not found", postID)
+	}
+	if err != nil {
+		return fmt.Errorf("error starring post: %v", err)
+	}
+
+	fmt.Printf("Starred %s\n", saved.Title)
+	return nil
+}
+
+// unstar takes the id of a post, or of a star whose post was deleted, and removes the star
+func handlerUnstar(s *state, cmd command, user database.User) error {
+	if len(cmd.Args) != 1 {
+		return fmt.Errorf("usage: unstar <post-id>")
+	}
+	id, err := uuid.Parse(cmd.Args[0])
+	if err != nil {
+		return fmt.Errorf("invalid post id: %s", cmd.Args[0])
+	}
+
+	removed, err := s.db.UnstarPost(context.Background(), database.UnstarPostParams{UserID: user.ID, ID: id})
+	if err != nil {
+		return fmt.Errorf("error unstarring post: %v", err)
+	}
+	if removed == 0 {
+		return fmt.Errorf("post %s is not starred", id)
+	}
+
+	fmt.Printf("Unstarred %s\n", id)
+	return nil
+}
+
+// starred lists the posts the user starred, newest star first, with their notes. posts that have since been deleted are listed by the id of their star, which read and unstar accept too
+func handlerStarred(s *state, cmd command, user database.User) error {
+	saved, err := s.db.GetSavedPosts(context.Background(), user.ID)
+	if err != nil {
+		return fmt.Errorf("error fetching starred posts: %v", err)
+	}
+	if len(saved) == 0 {
+		fmt.Println("No starred posts")
+		return nil
+	}
+
+	for _, p := range saved {
+		fmt.Printf("* %s\n", p.Title)
+		if p.PostID.Valid {
+			fmt.Printf("ID: %s\n", p.PostID.UUID)
+		} else {
+			fmt.Printf("ID: %s (the post was deleted, a copy is kept)\n", p.ID)
+		}
+		fmt.Printf("Feed: %s\n", p.FeedName)
+		fmt.Printf("Published at: %s\n", p.PublishedAt.Format(time.RFC3339))
+		fmt.Printf("Starred at: %s\n", p.SavedAt.Format(time.RFC3339))
+		fmt.Printf("Link: %s\n", p.Url)
+		if p.Notes.Valid {
+			fmt.Printf("Note: %s\n", p.Notes.String)
+		}
+		fmt.Println("---")
+	}
+	return nil
+}
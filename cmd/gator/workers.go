@@ -0,0 +1,149 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/jamesBoder/rss_aggreggator/internal/database"
+
+	"github.com/jamesBoder/rss_aggreggator/internal/feed"
+
+	"github.com/jamesBoder/rss_aggreggator/internal/schedule"
+)
+
+// claimLease is how long a claimed feed stays reserved for the worker fetching it. if the worker dies, the feed becomes due again once the lease runs out
+const claimLease = 10 * time.Minute
+
+// claimNextFeed claims the feed that has been due the longest, or returns sql.ErrNoRows if none is due
+func claimNextFeed(ctx context.Context, s *state) (database.Feed, error) {
+	now := time.Now()
+	return s.db.ClaimNextFeed(ctx, database.ClaimNextFeedParams{
+		Now:        sql.NullTime{Time: now, Valid: true},
+		LeaseUntil: sql.NullTime{Time: now.Add(claimLease), Valid: true},
+	})
+}
+
+// scheduleNextFetch stores when a feed is due again. parsed may be nil when the feed was not modified
+func scheduleNextFetch(ctx context.Context, s *state, feedID uuid.UUID, interval time.Duration, parsed *feed.Feed) error {
+	err := s.db.SetFeedSchedule(ctx, database.SetFeedScheduleParams{
+		ID:                   feedID,
+		NextFetchAt:          sql.NullTime{Time: schedule.Next(time.Now(), interval, parsed), Valid: true},
+		FetchIntervalSeconds: sql.NullInt32{Int32: int32(interval / time.Second), Valid: true},
+	})
+	if err != nil {
+		return fmt.Errorf("error scheduling next fetch: %v", err)
+	}
+	return nil
+}
+
+// hostLimiter caps the number of concurrent fetches against a single host, so a worker pool does not hammer one publisher
+type hostLimiter struct {
+	mu    sync.Mutex
+	limit int
+	slots map[string]chan struct{}
+}
+
+func newHostLimiter(limit int) *hostLimiter {
+	return &hostLimiter{
+		limit: limit,
+		slots: make(map[string]chan struct{}),
+	}
+}
+
+// acquire blocks until a slot for host is free and returns a function that releases it
+func (l *hostLimiter) acquire(host string) func() {
+	l.mu.Lock()
+	slot, ok := l.slots[host]
+	if !ok {
+		slot = make(chan struct{}, l.limit)
+		l.slots[host] = slot
+	}
+	l.mu.Unlock()
+
+	slot <- struct{}{}
+	return func() { <-slot }
+}
+
+// feedHost returns the host a feed is fetched from, used as the per-host limiter key
+func feedHost(feedURL string) string {
+	u, err := url.Parse(feedURL)
+	if err != nil {
+		return feedURL
+	}
+	return u.Hostname()
+}
+
+// scrapeRound runs workers that each claim the next due feed, fetch it and store its posts, until no feed is due
+func scrapeRound(ctx context.Context, s *state, workers int, limiter *hostLimiter) {
+	// ClaimNextFeed locks and leases a feed in one statement, so workers in this and other aggregator processes never claim the same feed
+	claim := func() (database.Feed, error) {
+		return claimNextFeed(ctx, s)
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				feed, err := claim()
+				if err == sql.ErrNoRows {
+					return
+				}
+				if err != nil {
+					fmt.Printf("Error claiming next feed: %v\n", err)
+					return
+				}
+
+				release := limiter.acquire(feedHost(feed.Url))
+				err = scrapeFeed(ctx, s, feed)
+				release()
+				if err != nil {
+					fmt.Printf("Error scraping feed %s: %v\n", feed.Name, err)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+// fetchArticles downloads the articles of up to limit posts of feeds in full content mode, with a pool of workers and the same per-host limit as feeds. it runs after the round's feeds are stored rather than inside their claims, so slow article pages cannot outlast a feed's lease
+func fetchArticles(ctx context.Context, s *state, workers, limit int, limiter *hostLimiter) {
+	if limit <= 0 {
+		return
+	}
+	posts, err := s.db.ClaimFullContentPosts(ctx, int32(limit))
+	if err != nil {
+		fmt.Printf("Error claiming posts to download: %v\n", err)
+		return
+	}
+
+	jobs := make(chan database.ClaimFullContentPostsRow)
+	var wg sync.WaitGroup
+	for i := 0; i < min(workers, len(posts)); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for post := range jobs {
+				release := limiter.acquire(feedHost(post.Url))
+				err := storeFullContent(ctx, s, post.ID, post.Url)
+				release()
+				// a missing article is not worth retrying, the post keeps its summary
+				if err != nil {
+					fmt.Printf("Error downloading article %s: %v\n", post.Url, err)
+				}
+			}
+		}()
+	}
+	for _, post := range posts {
+		jobs <- post
+	}
+	close(jobs)
+	wg.Wait()
+}
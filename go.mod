@@ -3,6 +3,10 @@ module github.com/jamesBoder/rss_aggreggator
 go 1.24.6
 
 require (
-	github.com/google/uuid v1.6.0 // indirect
-	github.com/lib/pq v1.10.9 // indirect
+	github.com/google/uuid v1.6.0
+	github.com/lib/pq v1.10.9
+	golang.org/x/term v0.40.0
+	golang.org/x/text v0.34.0
 )
+
+require golang.org/x/sys v0.41.0 // indirect
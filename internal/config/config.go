@@ -2,15 +2,96 @@ package config
 
 import (
 	"encoding/json"
+	"fmt"
 	"os"
 	"path/filepath"
+	"time"
 )
 
 const configFileName = ".gatorconfig.json"
 
+// DefaultDeadFeedFailures is the number of consecutive failures after which a feed is marked dead when dead_feed_failures is not set
+const DefaultDeadFeedFailures = 20
+
+// defaults for the fetcher settings that are left unset
+const (
+	DefaultFetchTimeout     = 30 * time.Second
+	DefaultUserAgent        = "gator"
+	DefaultMaxResponseBytes = 10 << 20 // 10 MiB
+	DefaultMaxItems         = 500
+)
+
 type Config struct {
 	DBUrl           string `json:"db_url"`
 	CurrentUserName string `json:"current_user_name"`
+
+	// DeadFeedFailures is the number of consecutive failed fetches after which a feed is marked dead. 0 means DefaultDeadFeedFailures
+	DeadFeedFailures int `json:"dead_feed_failures,omitempty"`
+
+	// Fetcher configures the HTTP client used to fetch feeds. nil means every setting uses its default, and keeps the section out of the file
+	Fetcher *FetcherConfig `json:"fetcher,omitempty"`
+
+	// DownloadDir is where enclosures are saved by the download command. empty means Downloads/gator in the home directory
+	DownloadDir string `json:"download_dir,omitempty"`
+}
+
+// FetcherConfig holds the settings of the HTTP client shared by all feed fetches. zero values fall back to the defaults above
+type FetcherConfig struct {
+	// Timeout bounds a whole request, including reading the body, as a Go duration such as "30s"
+	Timeout string `json:"timeout,omitempty"`
+
+	UserAgent string `json:"user_agent,omitempty"`
+
+	// Proxy is the URL of an HTTP(S) proxy. when empty, HTTP_PROXY, HTTPS_PROXY and NO_PROXY from the environment are used
+	Proxy string `json:"proxy,omitempty"`
+
+	// CABundle is a PEM file of extra certificate authorities trusted alongside the system ones
+	CABundle string `json:"ca_bundle,omitempty"`
+
+	// InsecureSkipVerify lists feed URLs or hostnames whose TLS certificates are not verified
+	InsecureSkipVerify []string `json:"insecure_skip_verify,omitempty"`
+
+	// MaxResponseBytes caps the size of a response body
+	MaxResponseBytes int64 `json:"max_response_bytes,omitempty"`
+
+	// MaxItems caps the number of items read from a single fetch. the rest of the document is not read
+	MaxItems int `json:"max_items,omitempty"`
+}
+
+// TimeoutDuration returns the parsed request timeout, or DefaultFetchTimeout when none is set
+func (f FetcherConfig) TimeoutDuration() (time.Duration, error) {
+	if f.Timeout == "" {
+		return DefaultFetchTimeout, nil
+	}
+	d, err := time.ParseDuration(f.Timeout)
+	if err != nil || d <= 0 {
+		return 0, fmt.Errorf("invalid fetcher timeout %q", f.Timeout)
+	}
+	return d, nil
+}
+
+// UserAgentOrDefault returns the configured user agent, or DefaultUserAgent when none is set
+func (f FetcherConfig) UserAgentOrDefault() string {
+	if f.UserAgent == "" {
+		return DefaultUserAgent
+	}
+	return f.UserAgent
+}
+
+// MaxResponseBytesOrDefault returns the configured response size cap, or DefaultMaxResponseBytes when none is set
+func (f FetcherConfig) MaxResponseBytesOrDefault() int64 {
+	if f.MaxResponseBytes <= 0 {
+		return DefaultMaxResponseBytes
+	}
+	return f.MaxResponseBytes
+}
+
+// MaxItemsOrDefault returns the configured item cap, or DefaultMaxItems when none is set
+func (f FetcherConfig) MaxItemsOrDefault() int {
+	if f.MaxItems <= 0 {
+		return DefaultMaxItems
+	}
+	return f.MaxItems
 }
 
 func getConfigFilePath() (string, error) {
@@ -21,6 +102,26 @@ func getConfigFilePath() (string, error) {
 	return filepath.Join(homeDir, configFileName), nil
 }
 
+// FetcherOrDefault returns the fetcher settings, or a zero FetcherConfig when the config has no fetcher section
+func (c *Config) FetcherOrDefault() FetcherConfig {
+	if c.Fetcher == nil {
+		return FetcherConfig{}
+	}
+	return *c.Fetcher
+}
+
+// DownloadDirOrDefault returns the configured download directory, or Downloads/gator in the home directory when none is set
+func (c *Config) DownloadDirOrDefault() (string, error) {
+	if c.DownloadDir != "" {
+		return c.DownloadDir, nil
+	}
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(homeDir, "Downloads", "gator"), nil
+}
+
 // Create a write function that writes the Config struct to the JSON config file
 func write(config *Config) error {
 	configFilePath, err := getConfigFilePath()
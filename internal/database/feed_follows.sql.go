@@ -137,3 +137,22 @@ func (q *Queries) GetFeedFollowsForUser(ctx context.Context, userID uuid.UUID) (
 	}
 	return items, nil
 }
+
+const moveFeedFollows = `-- name: MoveFeedFollows :exec
+INSERT INTO feed_follows (id, created_at, updated_at, user_id, feed_id)
+SELECT gen_random_uuid(), NOW(), NOW(), source.user_id, $1::uuid
+FROM feed_follows AS source
+WHERE source.feed_id = $2
+ON CONFLICT (user_id, feed_id) DO NOTHING
+`
+
+type MoveFeedFollowsParams struct {
+	ToFeedID   uuid.UUID
+	FromFeedID uuid.UUID
+}
+
+// MoveFeedFollows copies every follow of one feed to another, skipping users who already follow the target
+func (q *Queries) MoveFeedFollows(ctx context.Context, arg MoveFeedFollowsParams) error {
+	_, err := q.db.ExecContext(ctx, moveFeedFollows, arg.ToFeedID, arg.FromFeedID)
+	return err
+}
@@ -7,11 +7,84 @@ package database
 
 import (
 	"context"
+	"database/sql"
 	"time"
 
 	"github.com/google/uuid"
 )
 
+const claimNextFeed = `-- name: ClaimNextFeed :one
+UPDATE feeds
+SET last_fetched_at = $1, next_fetch_at = $2, updated_at = NOW()
+WHERE id = (
+    SELECT id
+    FROM feeds
+    WHERE status = 'active' AND (next_fetch_at IS NULL OR next_fetch_at <= $1)
+    ORDER BY next_fetch_at NULLS FIRST, last_fetched_at NULLS FIRST
+    LIMIT 1
+    FOR UPDATE SKIP LOCKED
+)
+RETURNING id, created_at, updated_at, name, url, user_id, last_fetched_at, etag, last_modified, next_fetch_at, fetch_interval_seconds, consecutive_failures, last_error, last_error_at, status, site_url, description, encoding, parse_warnings, fetch_full_content
+`
+
+type ClaimNextFeedParams struct {
+	Now        sql.NullTime
+	LeaseUntil sql.NullTime
+}
+
+// ClaimNextFeed atomically picks the active feed that has been due the longest and leases it until lease_until, so no other worker picks it up while it is being fetched. SKIP LOCKED lets several aggregator instances claim feeds at once without handing out the same feed twice
+func (q *Queries) ClaimNextFeed(ctx context.Context, arg ClaimNextFeedParams) (Feed, error) {
+	row := q.db.QueryRowContext(ctx, claimNextFeed, arg.Now, arg.LeaseUntil)
+	var i Feed
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Name,
+		&i.Url,
+		&i.UserID,
+		&i.LastFetchedAt,
+		&i.Etag,
+		&i.LastModified,
+		&i.NextFetchAt,
+		&i.FetchIntervalSeconds,
+		&i.ConsecutiveFailures,
+		&i.LastError,
+		&i.LastErrorAt,
+		&i.Status,
+		&i.SiteUrl,
+		&i.Description,
+		&i.Encoding,
+		&i.ParseWarnings,
+		&i.FetchFullContent,
+	)
+	return i, err
+}
+
+const deleteFeed = `-- name: DeleteFeed :exec
+DELETE FROM feeds
+WHERE id = $1
+`
+
+func (q *Queries) DeleteFeed(ctx context.Context, id uuid.UUID) error {
+	_, err := q.db.ExecContext(ctx, deleteFeed, id)
+	return err
+}
+
+const deleteUnfollowedFeeds = `-- name: DeleteUnfollowedFeeds :execrows
+DELETE FROM feeds
+WHERE NOT EXISTS (SELECT 1 FROM feed_follows WHERE feed_follows.feed_id = feeds.id)
+`
+
+// DeleteUnfollowedFeeds removes the feeds nobody follows any more, together with their posts. starred posts live on as their copies in saved_posts
+func (q *Queries) DeleteUnfollowedFeeds(ctx context.Context) (int64, error) {
+	result, err := q.db.ExecContext(ctx, deleteUnfollowedFeeds)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
 const getAllFeeds = `-- name: GetAllFeeds :many
 SELECT id, created_at, updated_at, name, url, user_id
 FROM feeds
@@ -114,35 +187,193 @@ func (q *Queries) GetFeedByURL(ctx context.Context, url string) (GetFeedByURLRow
 	return i, err
 }
 
-const getNextFeedToFetch = `-- name: GetNextFeedToFetch :one
-SELECT id, created_at, updated_at, name, url, user_id, last_fetched_at
+const getFeedsByStatus = `-- name: GetFeedsByStatus :many
+SELECT id, name, url, consecutive_failures, last_error, last_error_at
 FROM feeds
-ORDER BY last_fetched_at NULLS FIRST, updated_at ASC
-LIMIT 1
+WHERE status = $1
+ORDER BY name
 `
 
-func (q *Queries) GetNextFeedToFetch(ctx context.Context) (Feed, error) {
-	row := q.db.QueryRowContext(ctx, getNextFeedToFetch)
-	var i Feed
-	err := row.Scan(
-		&i.ID,
-		&i.CreatedAt,
-		&i.UpdatedAt,
-		&i.Name,
-		&i.Url,
-		&i.UserID,
-		&i.LastFetchedAt,
-	)
-	return i, err
+type GetFeedsByStatusRow struct {
+	ID                  uuid.UUID
+	Name                string
+	Url                 string
+	ConsecutiveFailures int32
+	LastError           sql.NullString
+	LastErrorAt         sql.NullTime
+}
+
+func (q *Queries) GetFeedsByStatus(ctx context.Context, status string) ([]GetFeedsByStatusRow, error) {
+	rows, err := q.db.QueryContext(ctx, getFeedsByStatus, status)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetFeedsByStatusRow
+	for rows.Next() {
+		var i GetFeedsByStatusRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Name,
+			&i.Url,
+			&i.ConsecutiveFailures,
+			&i.LastError,
+			&i.LastErrorAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
 }
 
-const markFeedFetched = `-- name: MarkFeedFetched :exec
+const recordFeedFailure = `-- name: RecordFeedFailure :exec
 UPDATE feeds
-SET last_fetched_at = NOW(), updated_at = NOW()
+SET consecutive_failures = consecutive_failures + 1, last_error = $2, last_error_at = NOW(), next_fetch_at = $3, updated_at = NOW()
 WHERE id = $1
 `
 
-func (q *Queries) MarkFeedFetched(ctx context.Context, id uuid.UUID) error {
-	_, err := q.db.ExecContext(ctx, markFeedFetched, id)
+type RecordFeedFailureParams struct {
+	ID          uuid.UUID
+	LastError   sql.NullString
+	NextFetchAt sql.NullTime
+}
+
+// RecordFeedFailure stores why a fetch failed and when to retry
+func (q *Queries) RecordFeedFailure(ctx context.Context, arg RecordFeedFailureParams) error {
+	_, err := q.db.ExecContext(ctx, recordFeedFailure, arg.ID, arg.LastError, arg.NextFetchAt)
+	return err
+}
+
+const resumeFeed = `-- name: ResumeFeed :exec
+UPDATE feeds
+SET status = 'active', consecutive_failures = 0, next_fetch_at = NULL, updated_at = NOW()
+WHERE id = $1
+`
+
+// ResumeFeed reactivates a paused or dead feed with a clean failure count and schedules it right away
+func (q *Queries) ResumeFeed(ctx context.Context, id uuid.UUID) error {
+	_, err := q.db.ExecContext(ctx, resumeFeed, id)
+	return err
+}
+
+const setFeedEncoding = `-- name: SetFeedEncoding :exec
+UPDATE feeds
+SET encoding = $2, updated_at = NOW()
+WHERE id = $1
+`
+
+type SetFeedEncodingParams struct {
+	ID       uuid.UUID
+	Encoding sql.NullString
+}
+
+func (q *Queries) SetFeedEncoding(ctx context.Context, arg SetFeedEncodingParams) error {
+	_, err := q.db.ExecContext(ctx, setFeedEncoding, arg.ID, arg.Encoding)
+	return err
+}
+
+const setFeedFetchFullContent = `-- name: SetFeedFetchFullContent :exec
+UPDATE feeds
+SET fetch_full_content = $2, updated_at = NOW()
+WHERE id = $1
+`
+
+type SetFeedFetchFullContentParams struct {
+	ID               uuid.UUID
+	FetchFullContent bool
+}
+
+func (q *Queries) SetFeedFetchFullContent(ctx context.Context, arg SetFeedFetchFullContentParams) error {
+	_, err := q.db.ExecContext(ctx, setFeedFetchFullContent, arg.ID, arg.FetchFullContent)
+	return err
+}
+
+const setFeedParseWarnings = `-- name: SetFeedParseWarnings :exec
+UPDATE feeds
+SET parse_warnings = $2, updated_at = NOW()
+WHERE id = $1
+`
+
+type SetFeedParseWarningsParams struct {
+	ID            uuid.UUID
+	ParseWarnings sql.NullString
+}
+
+func (q *Queries) SetFeedParseWarnings(ctx context.Context, arg SetFeedParseWarningsParams) error {
+	_, err := q.db.ExecContext(ctx, setFeedParseWarnings, arg.ID, arg.ParseWarnings)
+	return err
+}
+
+const setFeedSchedule = `-- name: SetFeedSchedule :exec
+UPDATE feeds
+SET next_fetch_at = $2, fetch_interval_seconds = $3, consecutive_failures = 0, updated_at = NOW()
+WHERE id = $1
+`
+
+type SetFeedScheduleParams struct {
+	ID                   uuid.UUID
+	NextFetchAt          sql.NullTime
+	FetchIntervalSeconds sql.NullInt32
+}
+
+// SetFeedSchedule records a successful fetch: it stores when the feed is due again and resets its failure count
+func (q *Queries) SetFeedSchedule(ctx context.Context, arg SetFeedScheduleParams) error {
+	_, err := q.db.ExecContext(ctx, setFeedSchedule, arg.ID, arg.NextFetchAt, arg.FetchIntervalSeconds)
+	return err
+}
+
+const setFeedStatus = `-- name: SetFeedStatus :exec
+UPDATE feeds
+SET status = $2, updated_at = NOW()
+WHERE id = $1
+`
+
+type SetFeedStatusParams struct {
+	ID     uuid.UUID
+	Status string
+}
+
+func (q *Queries) SetFeedStatus(ctx context.Context, arg SetFeedStatusParams) error {
+	_, err := q.db.ExecContext(ctx, setFeedStatus, arg.ID, arg.Status)
+	return err
+}
+
+const updateFeedCacheHeaders = `-- name: UpdateFeedCacheHeaders :exec
+UPDATE feeds
+SET etag = $2, last_modified = $3, updated_at = NOW()
+WHERE id = $1
+`
+
+type UpdateFeedCacheHeadersParams struct {
+	ID           uuid.UUID
+	Etag         sql.NullString
+	LastModified sql.NullString
+}
+
+func (q *Queries) UpdateFeedCacheHeaders(ctx context.Context, arg UpdateFeedCacheHeadersParams) error {
+	_, err := q.db.ExecContext(ctx, updateFeedCacheHeaders, arg.ID, arg.Etag, arg.LastModified)
+	return err
+}
+
+const updateFeedURL = `-- name: UpdateFeedURL :exec
+UPDATE feeds
+SET url = $2, updated_at = NOW()
+WHERE id = $1
+`
+
+type UpdateFeedURLParams struct {
+	ID  uuid.UUID
+	Url string
+}
+
+func (q *Queries) UpdateFeedURL(ctx context.Context, arg UpdateFeedURLParams) error {
+	_, err := q.db.ExecContext(ctx, updateFeedURL, arg.ID, arg.Url)
 	return err
 }
@@ -12,13 +12,26 @@ import (
 )
 
 type Feed struct {
-	ID            uuid.UUID
-	CreatedAt     time.Time
-	UpdatedAt     time.Time
-	Name          string
-	Url           string
-	UserID        uuid.UUID
-	LastFetchedAt sql.NullTime
+	ID                   uuid.UUID
+	CreatedAt            time.Time
+	UpdatedAt            time.Time
+	Name                 string
+	Url                  string
+	UserID               uuid.UUID
+	LastFetchedAt        sql.NullTime
+	Etag                 sql.NullString
+	LastModified         sql.NullString
+	NextFetchAt          sql.NullTime
+	FetchIntervalSeconds sql.NullInt32
+	ConsecutiveFailures  int32
+	LastError            sql.NullString
+	LastErrorAt          sql.NullTime
+	Status               string
+	SiteUrl              sql.NullString
+	Description          sql.NullString
+	Encoding             sql.NullString
+	ParseWarnings        sql.NullString
+	FetchFullContent     bool
 }
 
 type FeedFollow struct {
@@ -30,14 +43,61 @@ type FeedFollow struct {
 }
 
 type Post struct {
+	ID                   uuid.UUID
+	CreatedAt            time.Time
+	UpdatedAt            time.Time
+	Title                string
+	Url                  string
+	Description          sql.NullString
+	PublishedAt          time.Time
+	FeedID               uuid.UUID
+	PublishedAtEstimated bool
+	Guid                 string
+	Authors              sql.NullString
+	Content              sql.NullString
+	CommentsUrl          sql.NullString
+	Episode              sql.NullInt32
+	FullContent          sql.NullString
+	FullContentPending   bool
+}
+
+type PostCategory struct {
+	PostID   uuid.UUID
+	Category string
+}
+
+type PostEnclosure struct {
+	PostID          uuid.UUID
+	Position        int32
+	Url             string
+	MimeType        sql.NullString
+	Length          sql.NullInt64
+	DurationSeconds sql.NullInt32
+}
+
+type PostRead struct {
+	UserID uuid.UUID
+	PostID uuid.UUID
+	ReadAt time.Time
+}
+
+type PrunedPost struct {
+	FeedID   uuid.UUID
+	Guid     string
+	PrunedAt time.Time
+}
+
+type SavedPost struct {
 	ID          uuid.UUID
-	CreatedAt   time.Time
-	UpdatedAt   time.Time
+	UserID      uuid.UUID
+	PostID      uuid.NullUUID
+	Notes       sql.NullString
+	SavedAt     time.Time
 	Title       string
 	Url         string
-	Description sql.NullString
+	Content     sql.NullString
+	FeedName    string
 	PublishedAt time.Time
-	FeedID      uuid.UUID
 }
 
 type User struct {
@@ -11,46 +11,121 @@ import (
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/lib/pq"
 )
 
+const claimFullContentPosts = `-- name: ClaimFullContentPosts :many
+UPDATE posts
+SET full_content_pending = FALSE
+WHERE posts.id IN (
+    SELECT pending.id
+    FROM posts AS pending
+    INNER JOIN feeds ON pending.feed_id = feeds.id
+    WHERE pending.full_content_pending AND feeds.fetch_full_content
+    ORDER BY pending.created_at DESC
+    LIMIT $1
+    FOR UPDATE OF pending SKIP LOCKED
+)
+RETURNING posts.id, posts.url
+`
+
+type ClaimFullContentPostsRow struct {
+	ID  uuid.UUID
+	Url string
+}
+
+// ClaimFullContentPosts picks up to limit posts whose article is still to be downloaded, newest first, and clears their pending flag so no other aggregator downloads them too. a post whose download fails is not tried again. posts of feeds that have left full content mode since are skipped
+func (q *Queries) ClaimFullContentPosts(ctx context.Context, limit int32) ([]ClaimFullContentPostsRow, error) {
+	rows, err := q.db.QueryContext(ctx, claimFullContentPosts, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ClaimFullContentPostsRow
+	for rows.Next() {
+		var i ClaimFullContentPostsRow
+		if err := rows.Scan(&i.ID, &i.Url); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const createPost = `-- name: CreatePost :one
-INSERT INTO posts (id, created_at, updated_at, feed_id, title, url, description, published_at)
-VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
-RETURNING id, created_at, updated_at, feed_id, title, url, description, published_at
+INSERT INTO posts (id, created_at, updated_at, feed_id, guid, title, url, description, published_at, published_at_estimated, authors, content, comments_url, episode, full_content_pending)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15)
+ON CONFLICT (feed_id, guid) DO UPDATE
+SET updated_at = EXCLUDED.updated_at,
+    title = EXCLUDED.title,
+    url = EXCLUDED.url,
+    description = EXCLUDED.description,
+    published_at = CASE WHEN EXCLUDED.published_at_estimated THEN posts.published_at ELSE EXCLUDED.published_at END,
+    published_at_estimated = posts.published_at_estimated AND EXCLUDED.published_at_estimated,
+    authors = EXCLUDED.authors,
+    content = EXCLUDED.content,
+    comments_url = EXCLUDED.comments_url,
+    episode = EXCLUDED.episode
+WHERE (posts.title, posts.url, posts.description, posts.authors, posts.content, posts.comments_url, posts.episode) IS DISTINCT FROM (EXCLUDED.title, EXCLUDED.url, EXCLUDED.description, EXCLUDED.authors, EXCLUDED.content, EXCLUDED.comments_url, EXCLUDED.episode)
+   OR (NOT EXCLUDED.published_at_estimated AND posts.published_at IS DISTINCT FROM EXCLUDED.published_at)
+RETURNING id, created_at, updated_at, feed_id, guid, title, url, description, published_at, published_at_estimated
 `
 
 type CreatePostParams struct {
-	ID          uuid.UUID
-	CreatedAt   time.Time
-	UpdatedAt   time.Time
-	FeedID      uuid.UUID
-	Title       string
-	Url         string
-	Description sql.NullString
-	PublishedAt time.Time
+	ID                   uuid.UUID
+	CreatedAt            time.Time
+	UpdatedAt            time.Time
+	FeedID               uuid.UUID
+	Guid                 string
+	Title                string
+	Url                  string
+	Description          sql.NullString
+	PublishedAt          time.Time
+	PublishedAtEstimated bool
+	Authors              sql.NullString
+	Content              sql.NullString
+	CommentsUrl          sql.NullString
+	Episode              sql.NullInt32
+	FullContentPending   bool
 }
 
 type CreatePostRow struct {
-	ID          uuid.UUID
-	CreatedAt   time.Time
-	UpdatedAt   time.Time
-	FeedID      uuid.UUID
-	Title       string
-	Url         string
-	Description sql.NullString
-	PublishedAt time.Time
+	ID                   uuid.UUID
+	CreatedAt            time.Time
+	UpdatedAt            time.Time
+	FeedID               uuid.UUID
+	Guid                 string
+	Title                string
+	Url                  string
+	Description          sql.NullString
+	PublishedAt          time.Time
+	PublishedAtEstimated bool
 }
 
+// CreatePost upserts on (feed_id, guid). An existing post is only rewritten when it changed; unchanged posts return no row. full_content_pending only applies to new posts
 func (q *Queries) CreatePost(ctx context.Context, arg CreatePostParams) (CreatePostRow, error) {
 	row := q.db.QueryRowContext(ctx, createPost,
 		arg.ID,
 		arg.CreatedAt,
 		arg.UpdatedAt,
 		arg.FeedID,
+		arg.Guid,
 		arg.Title,
 		arg.Url,
 		arg.Description,
 		arg.PublishedAt,
+		arg.PublishedAtEstimated,
+		arg.Authors,
+		arg.Content,
+		arg.CommentsUrl,
+		arg.Episode,
+		arg.FullContentPending,
 	)
 	var i CreatePostRow
 	err := row.Scan(
@@ -58,42 +133,129 @@ func (q *Queries) CreatePost(ctx context.Context, arg CreatePostParams) (CreateP
 		&i.CreatedAt,
 		&i.UpdatedAt,
 		&i.FeedID,
+		&i.Guid,
 		&i.Title,
 		&i.Url,
 		&i.Description,
 		&i.PublishedAt,
+		&i.PublishedAtEstimated,
 	)
 	return i, err
 }
 
-const getPostsForUser = `-- name: GetPostsForUser :many
-SELECT posts.id, posts.created_at, posts.updated_at, posts.feed_id, posts.title, posts.url, posts.description, posts.published_at
+const deleteOldPosts = `-- name: DeleteOldPosts :one
+WITH deleted AS (
+    DELETE FROM posts
+    WHERE posts.published_at < $1
+      AND NOT EXISTS (SELECT 1 FROM saved_posts WHERE saved_posts.post_id = posts.id)
+    RETURNING posts.feed_id, posts.guid
+), pruned AS (
+    INSERT INTO pruned_posts (feed_id, guid)
+    SELECT deleted.feed_id, deleted.guid FROM deleted
+    ON CONFLICT (feed_id, guid) DO NOTHING
+)
+SELECT count(*) FROM deleted
+`
+
+// DeleteOldPosts removes the posts published before the cutoff, except those a user has starred, and keeps their guids in pruned_posts so the next fetch does not store them again. it returns how many posts were deleted, not how many guids were newly kept
+func (q *Queries) DeleteOldPosts(ctx context.Context, publishedAt time.Time) (int64, error) {
+	row := q.db.QueryRowContext(ctx, deleteOldPosts, publishedAt)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
+const getPostForUser = `-- name: GetPostForUser :one
+SELECT posts.id, posts.title, posts.url, posts.description, posts.published_at, posts.published_at_estimated, posts.authors, posts.content, posts.full_content, feeds.name AS feed_name
 FROM posts
 INNER JOIN feeds ON posts.feed_id = feeds.id
-WHERE feeds.user_id = $1
+INNER JOIN feed_follows ON feed_follows.feed_id = posts.feed_id
+WHERE posts.id = $1 AND feed_follows.user_id = $2
+`
+
+type GetPostForUserParams struct {
+	ID     uuid.UUID
+	UserID uuid.UUID
+}
+
+type GetPostForUserRow struct {
+	ID                   uuid.UUID
+	Title                string
+	Url                  string
+	Description          sql.NullString
+	PublishedAt          time.Time
+	PublishedAtEstimated bool
+	Authors              sql.NullString
+	Content              sql.NullString
+	FullContent          sql.NullString
+	FeedName             string
+}
+
+// GetPostForUser returns one post with everything read shows, as long as it belongs to a feed the user follows
+func (q *Queries) GetPostForUser(ctx context.Context, arg GetPostForUserParams) (GetPostForUserRow, error) {
+	row := q.db.QueryRowContext(ctx, getPostForUser, arg.ID, arg.UserID)
+	var i GetPostForUserRow
+	err := row.Scan(
+		&i.ID,
+		&i.Title,
+		&i.Url,
+		&i.Description,
+		&i.PublishedAt,
+		&i.PublishedAtEstimated,
+		&i.Authors,
+		&i.Content,
+		&i.FullContent,
+		&i.FeedName,
+	)
+	return i, err
+}
+
+const getPostsForUser = `-- name: GetPostsForUser :many
+SELECT posts.id, posts.created_at, posts.updated_at, posts.feed_id, posts.title, posts.url, posts.description, posts.published_at, posts.published_at_estimated, posts.authors, posts.content, posts.comments_url, posts.episode,
+    COALESCE((SELECT string_agg(category, ', ' ORDER BY category) FROM post_categories WHERE post_categories.post_id = posts.id), '')::text AS categories,
+    (post_reads.post_id IS NOT NULL)::bool AS read
+FROM posts
+INNER JOIN feed_follows ON feed_follows.feed_id = posts.feed_id
+LEFT JOIN post_reads ON post_reads.post_id = posts.id AND post_reads.user_id = $1
+WHERE feed_follows.user_id = $1
+  AND ($2::bool OR post_reads.post_id IS NULL)
 ORDER BY posts.published_at DESC
-LIMIT $2 OFFSET $3
+LIMIT $4 OFFSET $3
 `
 
 type GetPostsForUserParams struct {
-	UserID uuid.UUID
-	Limit  int32
-	Offset int32
+	UserID      uuid.UUID
+	IncludeRead bool
+	Offset      int32
+	Limit       int32
 }
 
 type GetPostsForUserRow struct {
-	ID          uuid.UUID
-	CreatedAt   time.Time
-	UpdatedAt   time.Time
-	FeedID      uuid.UUID
-	Title       string
-	Url         string
-	Description sql.NullString
-	PublishedAt time.Time
+	ID                   uuid.UUID
+	CreatedAt            time.Time
+	UpdatedAt            time.Time
+	FeedID               uuid.UUID
+	Title                string
+	Url                  string
+	Description          sql.NullString
+	PublishedAt          time.Time
+	PublishedAtEstimated bool
+	Authors              sql.NullString
+	Content              sql.NullString
+	CommentsUrl          sql.NullString
+	Episode              sql.NullInt32
+	Categories           string
+	Read                 bool
 }
 
+// GetPostsForUser returns the newest posts of the feeds the user follows. posts the user has read are left out unless include_read is set
 func (q *Queries) GetPostsForUser(ctx context.Context, arg GetPostsForUserParams) ([]GetPostsForUserRow, error) {
-	rows, err := q.db.QueryContext(ctx, getPostsForUser, arg.UserID, arg.Limit, arg.Offset)
+	rows, err := q.db.QueryContext(ctx, getPostsForUser,
+		arg.UserID,
+		arg.IncludeRead,
+		arg.Offset,
+		arg.Limit,
+	)
 	if err != nil {
 		return nil, err
 	}
@@ -110,6 +272,13 @@ func (q *Queries) GetPostsForUser(ctx context.Context, arg GetPostsForUserParams
 			&i.Url,
 			&i.Description,
 			&i.PublishedAt,
+			&i.PublishedAtEstimated,
+			&i.Authors,
+			&i.Content,
+			&i.CommentsUrl,
+			&i.Episode,
+			&i.Categories,
+			&i.Read,
 		); err != nil {
 			return nil, err
 		}
@@ -123,3 +292,91 @@ func (q *Queries) GetPostsForUser(ctx context.Context, arg GetPostsForUserParams
 	}
 	return items, nil
 }
+
+const getPrunedGUIDs = `-- name: GetPrunedGUIDs :many
+SELECT guid
+FROM pruned_posts
+WHERE feed_id = $1 AND guid = ANY($2::text[])
+`
+
+type GetPrunedGUIDsParams struct {
+	FeedID uuid.UUID
+	Guids  []string
+}
+
+// GetPrunedGUIDs returns which of the given guids belong to posts of the feed that prune deleted
+func (q *Queries) GetPrunedGUIDs(ctx context.Context, arg GetPrunedGUIDsParams) ([]string, error) {
+	rows, err := q.db.QueryContext(ctx, getPrunedGUIDs, arg.FeedID, pq.Array(arg.Guids))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []string
+	for rows.Next() {
+		var guid string
+		if err := rows.Scan(&guid); err != nil {
+			return nil, err
+		}
+		items = append(items, guid)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const movePosts = `-- name: MovePosts :exec
+UPDATE posts
+SET feed_id = $1, updated_at = NOW()
+WHERE posts.feed_id = $2
+  AND posts.guid NOT IN (SELECT existing.guid FROM posts AS existing WHERE existing.feed_id = $1)
+`
+
+type MovePostsParams struct {
+	ToFeedID   uuid.UUID
+	FromFeedID uuid.UUID
+}
+
+// MovePosts reassigns the posts of one feed to another, leaving behind posts the target already has
+func (q *Queries) MovePosts(ctx context.Context, arg MovePostsParams) error {
+	_, err := q.db.ExecContext(ctx, movePosts, arg.ToFeedID, arg.FromFeedID)
+	return err
+}
+
+const movePrunedPosts = `-- name: MovePrunedPosts :exec
+INSERT INTO pruned_posts (feed_id, guid, pruned_at)
+SELECT $1::uuid, source.guid, source.pruned_at
+FROM pruned_posts AS source
+WHERE source.feed_id = $2
+ON CONFLICT (feed_id, guid) DO NOTHING
+`
+
+type MovePrunedPostsParams struct {
+	ToFeedID   uuid.UUID
+	FromFeedID uuid.UUID
+}
+
+// MovePrunedPosts hands the pruned guids of one feed over to another when the feed moved
+func (q *Queries) MovePrunedPosts(ctx context.Context, arg MovePrunedPostsParams) error {
+	_, err := q.db.ExecContext(ctx, movePrunedPosts, arg.ToFeedID, arg.FromFeedID)
+	return err
+}
+
+const setPostFullContent = `-- name: SetPostFullContent :exec
+UPDATE posts
+SET full_content = $2, updated_at = NOW()
+WHERE id = $1
+`
+
+type SetPostFullContentParams struct {
+	ID          uuid.UUID
+	FullContent sql.NullString
+}
+
+func (q *Queries) SetPostFullContent(ctx context.Context, arg SetPostFullContentParams) error {
+	_, err := q.db.ExecContext(ctx, setPostFullContent, arg.ID, arg.FullContent)
+	return err
+}
@@ -7,31 +7,36 @@ package database
 
 import (
 	"context"
+	"database/sql"
 	"time"
 
 	"github.com/google/uuid"
 )
 
 const createFeed = `-- name: CreateFeed :one
-INSERT INTO feeds (id, created_at, updated_at, name, url, user_id)
+INSERT INTO feeds (id, created_at, updated_at, name, url, user_id, site_url, description)
 VALUES (
     $1,
     $2,
     $3,
     $4,
     $5,
-    $6
+    $6,
+    $7,
+    $8
 )
-RETURNING id, created_at, updated_at, name, url, user_id, last_fetched_at
+RETURNING id, created_at, updated_at, name, url, user_id, last_fetched_at, etag, last_modified, next_fetch_at, fetch_interval_seconds, consecutive_failures, last_error, last_error_at, status, site_url, description, encoding, parse_warnings, fetch_full_content
 `
 
 type CreateFeedParams struct {
-	ID        uuid.UUID
-	CreatedAt time.Time
-	UpdatedAt time.Time
-	Name      string
-	Url       string
-	UserID    uuid.UUID
+	ID          uuid.UUID
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+	Name        string
+	Url         string
+	UserID      uuid.UUID
+	SiteUrl     sql.NullString
+	Description sql.NullString
 }
 
 func (q *Queries) CreateFeed(ctx context.Context, arg CreateFeedParams) (Feed, error) {
@@ -42,6 +47,8 @@ func (q *Queries) CreateFeed(ctx context.Context, arg CreateFeedParams) (Feed, e
 		arg.Name,
 		arg.Url,
 		arg.UserID,
+		arg.SiteUrl,
+		arg.Description,
 	)
 	var i Feed
 	err := row.Scan(
@@ -52,6 +59,19 @@ func (q *Queries) CreateFeed(ctx context.Context, arg CreateFeedParams) (Feed, e
 		&i.Url,
 		&i.UserID,
 		&i.LastFetchedAt,
+		&i.Etag,
+		&i.LastModified,
+		&i.NextFetchAt,
+		&i.FetchIntervalSeconds,
+		&i.ConsecutiveFailures,
+		&i.LastError,
+		&i.LastErrorAt,
+		&i.Status,
+		&i.SiteUrl,
+		&i.Description,
+		&i.Encoding,
+		&i.ParseWarnings,
+		&i.FetchFullContent,
 	)
 	return i, err
 }
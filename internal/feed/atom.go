@@ -0,0 +1,207 @@
+package feed
+
+import (
+	"encoding/xml"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/jamesBoder/rss_aggreggator/internal/htmlutil"
+)
+
+// atomEntry is the wire format of an Atom <entry>. the fields are qualified with the Atom namespace so that extension elements with the same local name, such as <media:content> or <itunes:title>, do not land in them
+type atomEntry struct {
+	ID         string         `xml:"http://www.w3.org/2005/Atom id"`
+	Title      atomText       `xml:"http://www.w3.org/2005/Atom title"`
+	Links      []atomLink     `xml:"http://www.w3.org/2005/Atom link"`
+	Summary    atomText       `xml:"http://www.w3.org/2005/Atom summary"`
+	Content    atomText       `xml:"http://www.w3.org/2005/Atom content"`
+	Published  string         `xml:"http://www.w3.org/2005/Atom published"`
+	Updated    string         `xml:"http://www.w3.org/2005/Atom updated"`
+	Authors    []atomPerson   `xml:"http://www.w3.org/2005/Atom author"`
+	Categories []atomCategory `xml:"http://www.w3.org/2005/Atom category"`
+	podcast
+}
+
+// atomLink is an Atom <link>. An empty rel means "alternate"
+type atomLink struct {
+	Href   string `xml:"href,attr"`
+	Rel    string `xml:"rel,attr"`
+	Type   string `xml:"type,attr"`
+	Length string `xml:"length,attr"`
+}
+
+// atomPerson is an Atom person construct
+type atomPerson struct {
+	Name  string `xml:"name"`
+	Email string `xml:"email"`
+	URI   string `xml:"uri"`
+}
+
+// atomCategory is an Atom <category>; the label is optional and falls back to the term
+type atomCategory struct {
+	Term  string `xml:"term,attr"`
+	Label string `xml:"label,attr"`
+}
+
+// atomText is an Atom text construct. type="xhtml" content is nested markup, so keep the inner XML as well as the character data
+type atomText struct {
+	Type  string `xml:"type,attr"`
+	Body  string `xml:",chardata"`
+	Inner string `xml:",innerxml"`
+}
+
+// String returns the text of the construct, using the raw markup for xhtml content
+func (t atomText) String() string {
+	if t.Type == "xhtml" {
+		return strings.TrimSpace(t.Inner)
+	}
+	return strings.TrimSpace(t.Body)
+}
+
+// Text returns the construct as plain text, for titles. html and xhtml constructs are markup, which is rendered down to its text; text constructs are returned as they are
+func (t atomText) Text() string {
+	switch t.Type {
+	case "html", "xhtml":
+		return htmlutil.InlineText(t.String())
+	}
+	return t.String()
+}
+
+// alternateLink picks the rel="alternate" link, preferring text/html, and falls back to the first link with an href
+func alternateLink(links []atomLink) string {
+	best := ""
+	for _, l := range links {
+		if l.Href == "" || (l.Rel != "" && l.Rel != "alternate") {
+			continue
+		}
+		if l.Type == "" || l.Type == "text/html" {
+			return l.Href
+		}
+		if best == "" {
+			best = l.Href
+		}
+	}
+	if best != "" {
+		return best
+	}
+	for _, l := range links {
+		if l.Href != "" && l.Rel != "self" && l.Rel != "enclosure" && l.Rel != "replies" {
+			return l.Href
+		}
+	}
+	return ""
+}
+
+// AtomParser parses Atom 1.0 documents
+type AtomParser struct{}
+
+func (AtomParser) Name() string { return "atom" }
+
+func (AtomParser) Detect(contentType string, head []byte) bool {
+	return rootElement(head) == "feed"
+}
+
+func (AtomParser) Parse(r io.Reader, maxItems int) (*Feed, error) {
+	f := &Feed{}
+	var (
+		sy              syndication
+		title, subtitle atomText
+		links           []atomLink
+	)
+	warnings, err := walkXML(r, []string{"feed"}, func(d *xml.Decoder, start xml.StartElement) error {
+		if start.Name.Space == nsSyndication {
+			return sy.decode(d, start)
+		}
+		// extension elements such as <itunes:title> share local names with Atom's own
+		if start.Name.Space != nsAtom {
+			return d.Skip()
+		}
+
+		switch start.Name.Local {
+		case "entry":
+			if maxItems > 0 && len(f.Items) == maxItems {
+				f.Truncated = true
+				return errStop
+			}
+			var e atomEntry
+			if err := d.DecodeElement(&e, &start); err != nil {
+				return err
+			}
+			f.Items = append(f.Items, e.item())
+			return nil
+		case "title":
+			return d.DecodeElement(&title, &start)
+		case "subtitle":
+			return d.DecodeElement(&subtitle, &start)
+		case "link":
+			var l atomLink
+			if err := d.DecodeElement(&l, &start); err != nil {
+				return err
+			}
+			links = append(links, l)
+			return nil
+		}
+		return d.Skip()
+	})
+	f.Warnings = warnings
+	if err != nil && !keepPartial(f, err) {
+		return nil, err
+	}
+
+	f.Title = title.Text()
+	f.Link = alternateLink(links)
+	f.Description = subtitle.Text()
+	f.UpdateInterval = sy.interval()
+	return f, nil
+}
+
+// item converts the wire format into an Item
+func (e atomEntry) item() Item {
+	item := Item{
+		GUID:      strings.TrimSpace(e.ID),
+		Title:     e.Title.Text(),
+		Link:      alternateLink(e.Links),
+		Summary:   e.Summary.String(),
+		Content:   e.Content.String(),
+		Published: parseDate(e.Published),
+		Updated:   parseDate(e.Updated),
+	}
+	// published is optional in Atom, updated is required
+	if item.Published.IsZero() {
+		item.Published = item.Updated
+	}
+	for _, a := range e.Authors {
+		item.Authors = append(item.Authors, Person{
+			Name:  strings.TrimSpace(a.Name),
+			Email: strings.TrimSpace(a.Email),
+			URL:   strings.TrimSpace(a.URI),
+		})
+	}
+	for _, c := range e.Categories {
+		label := c.Label
+		if label == "" {
+			label = c.Term
+		}
+		if label = strings.TrimSpace(label); label != "" {
+			item.Categories = append(item.Categories, label)
+		}
+	}
+	for _, l := range e.Links {
+		if l.Href == "" {
+			continue
+		}
+		switch l.Rel {
+		case "enclosure":
+			length, _ := strconv.ParseInt(strings.TrimSpace(l.Length), 10, 64)
+			item.Enclosures = append(item.Enclosures, Enclosure{URL: l.Href, Type: l.Type, Length: length})
+		case "replies":
+			// the threading extension (RFC 4685) links to the comments; prefer the HTML page over a comments feed
+			if item.Comments == "" || l.Type == "text/html" {
+				item.Comments = l.Href
+			}
+		}
+	}
+	e.podcast.apply(&item)
+	return item
+}
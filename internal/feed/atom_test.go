@@ -0,0 +1,158 @@
+package feed
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestAtomEntry(t *testing.T) {
+	tests := []struct {
+		name  string
+		entry string
+		want  Item
+	}{
+		{
+			name: "media content does not replace the content",
+			entry: `<entry>
+				<id>urn:1</id>
+				<title>Episode 1</title>
+				<link href="https://example.com/1"/>
+				<content type="html">&lt;p&gt;Show notes&lt;/p&gt;</content>
+				<media:content url="https://cdn.example.com/1.mp3" type="audio/mpeg" fileSize="1000"/>
+				<updated>2024-01-02T03:04:05Z</updated>
+			</entry>`,
+			want: Item{
+				GUID:       "urn:1",
+				Title:      "Episode 1",
+				Link:       "https://example.com/1",
+				Content:    "<p>Show notes</p>",
+				Enclosures: []Enclosure{{URL: "https://cdn.example.com/1.mp3", Type: "audio/mpeg", Length: 1000}},
+			},
+		},
+		{
+			name: "itunes title does not replace the title",
+			entry: `<entry>
+				<id>urn:2</id>
+				<title>Atom title</title>
+				<itunes:title>iTunes title</itunes:title>
+				<itunes:episode>7</itunes:episode>
+				<updated>2024-01-02T03:04:05Z</updated>
+			</entry>`,
+			want: Item{GUID: "urn:2", Title: "Atom title", Episode: 7},
+		},
+		{
+			name: "text title is kept as it is",
+			entry: `<entry>
+				<id>urn:4</id>
+				<title>Why &lt;script&gt; tags block rendering</title>
+				<updated>2024-01-02T03:04:05Z</updated>
+			</entry>`,
+			want: Item{GUID: "urn:4", Title: "Why <script> tags block rendering"},
+		},
+		{
+			name: "html title is rendered as text",
+			entry: `<entry>
+				<id>urn:5</id>
+				<title type="html">Why &lt;code&gt;&amp;lt;script&amp;gt;&lt;/code&gt; tags &lt;em&gt;block&lt;/em&gt; rendering</title>
+				<updated>2024-01-02T03:04:05Z</updated>
+			</entry>`,
+			want: Item{GUID: "urn:5", Title: "Why <script> tags block rendering"},
+		},
+		{
+			name: "xhtml title is rendered as text",
+			entry: `<entry>
+				<id>urn:6</id>
+				<title type="xhtml"><div xmlns="http://www.w3.org/1999/xhtml">A <b>bold</b> title</div></title>
+				<updated>2024-01-02T03:04:05Z</updated>
+			</entry>`,
+			want: Item{GUID: "urn:6", Title: "A bold title"},
+		},
+		{
+			name: "replies link is the comments, not the link",
+			entry: `<entry>
+				<id>urn:3</id>
+				<title>Post</title>
+				<link rel="replies" type="text/html" href="https://example.com/3#comments"/>
+				<link href="https://example.com/3"/>
+				<updated>2024-01-02T03:04:05Z</updated>
+			</entry>`,
+			want: Item{GUID: "urn:3", Title: "Post", Link: "https://example.com/3", Comments: "https://example.com/3#comments"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc := `<?xml version="1.0"?>
+<feed xmlns="http://www.w3.org/2005/Atom" xmlns:media="http://search.yahoo.com/mrss/" xmlns:itunes="http://www.itunes.com/dtds/podcast-1.0.dtd">
+<title>Feed</title>` + tt.entry + `</feed>`
+			f, err := Parse([]byte(doc), "application/atom+xml")
+			if err != nil {
+				t.Fatalf("Parse: %v", err)
+			}
+			if len(f.Items) != 1 {
+				t.Fatalf("got %d items, want 1", len(f.Items))
+			}
+			got := f.Items[0]
+			want := tt.want
+			want.Published = time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+			want.Updated = want.Published
+			assertItem(t, got, want)
+		})
+	}
+}
+
+func TestAtomFeedIgnoresExtensionTitle(t *testing.T) {
+	doc := `<feed xmlns="http://www.w3.org/2005/Atom" xmlns:itunes="http://www.itunes.com/dtds/podcast-1.0.dtd">
+<title>Atom feed</title><itunes:title>Show</itunes:title></feed>`
+	f, err := Parse([]byte(doc), "")
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	if f.Title != "Atom feed" {
+		t.Errorf("Title = %q, want %q", f.Title, "Atom feed")
+	}
+}
+
+// assertItem compares the fields the parsers fill in
+func assertItem(t *testing.T, got, want Item) {
+	t.Helper()
+	check := func(field, got, want string) {
+		if got != want {
+			t.Errorf("%s = %q, want %q", field, got, want)
+		}
+	}
+	check("GUID", got.GUID, want.GUID)
+	check("Title", got.Title, want.Title)
+	check("Link", got.Link, want.Link)
+	check("Summary", strings.TrimSpace(got.Summary), want.Summary)
+	check("Content", strings.TrimSpace(got.Content), want.Content)
+	check("Comments", got.Comments, want.Comments)
+	if !got.Published.Equal(want.Published) {
+		t.Errorf("Published = %v, want %v", got.Published, want.Published)
+	}
+	if got.Episode != want.Episode {
+		t.Errorf("Episode = %d, want %d", got.Episode, want.Episode)
+	}
+	if strings.Join(got.Categories, ",") != strings.Join(want.Categories, ",") {
+		t.Errorf("Categories = %q, want %q", got.Categories, want.Categories)
+	}
+	if len(got.Authors) != len(want.Authors) {
+		t.Errorf("Authors = %+v, want %+v", got.Authors, want.Authors)
+	} else {
+		for i := range want.Authors {
+			if got.Authors[i] != want.Authors[i] {
+				t.Errorf("Authors[%d] = %+v, want %+v", i, got.Authors[i], want.Authors[i])
+			}
+		}
+	}
+	if len(got.Enclosures) != len(want.Enclosures) {
+		t.Errorf("Enclosures = %+v, want %+v", got.Enclosures, want.Enclosures)
+	} else {
+		for i := range want.Enclosures {
+			if got.Enclosures[i] != want.Enclosures[i] {
+				t.Errorf("Enclosures[%d] = %+v, want %+v", i, got.Enclosures[i], want.Enclosures[i])
+			}
+		}
+	}
+}
@@ -0,0 +1,99 @@
+package feed
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"mime"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/encoding/htmlindex"
+	"golang.org/x/text/transform"
+)
+
+// charset is a character encoding a document can be written in
+type charset struct {
+	name string            // canonical name, e.g. "utf-8" or "windows-1251"
+	enc  encoding.Encoding // nil for UTF-8, which needs no transcoding
+}
+
+var xmlEncoding = regexp.MustCompile(`^\s*<\?xml[^>]*?\sencoding\s*=\s*["']([A-Za-z0-9._:-]+)["']`)
+
+// detectCharset returns the encoding a document is written in: a byte order mark or the UTF-16 form of "<?" first, then the charset parameter of the Content-Type header, which takes precedence over the XML declaration, and UTF-8 when none names one. a label that names no known encoding is passed over for the next one; when none is left, the document is read as UTF-8 as long as its bytes are valid UTF-8
+func detectCharset(contentType string, head []byte) (charset, error) {
+	switch {
+	case bytes.HasPrefix(head, []byte("\ufeff")):
+		return lookupCharset("utf-8")
+	case bytes.HasPrefix(head, []byte{0xFE, 0xFF}), bytes.HasPrefix(head, []byte{0x00, '<', 0x00, '?'}):
+		return lookupCharset("utf-16be")
+	case bytes.HasPrefix(head, []byte{0xFF, 0xFE}), bytes.HasPrefix(head, []byte{'<', 0x00, '?', 0x00}):
+		return lookupCharset("utf-16le")
+	}
+
+	declared := ""
+	if m := xmlEncoding.FindSubmatch(head); m != nil {
+		declared = string(m[1])
+	}
+	var labels []string
+	if _, params, err := mime.ParseMediaType(contentType); err == nil && params["charset"] != "" {
+		// many servers label every response UTF-8. when the bytes prove that wrong, trust the document's own declaration
+		if declared == "" || !strings.EqualFold(params["charset"], "utf-8") || validUTF8Prefix(head) {
+			labels = append(labels, params["charset"])
+		}
+	}
+	if declared != "" {
+		labels = append(labels, declared)
+	}
+
+	for _, label := range labels {
+		cs, err := lookupCharset(label)
+		// the declaration of a UTF-16 document was found in ASCII bytes, so it is wrong
+		if err != nil || strings.HasPrefix(cs.name, "utf-16") {
+			continue
+		}
+		return cs, nil
+	}
+	if len(labels) > 0 && !validUTF8Prefix(head) {
+		return charset{}, fmt.Errorf("unsupported charset %q", labels[0])
+	}
+	return lookupCharset("utf-8")
+}
+
+// validUTF8Prefix reports whether head is valid UTF-8, allowing for a rune cut off at its end
+func validUTF8Prefix(head []byte) bool {
+	for i := 0; i < utf8.UTFMax-1 && len(head) > 0 && !utf8.Valid(head); i++ {
+		head = head[:len(head)-1]
+	}
+	return utf8.Valid(head)
+}
+
+// lookupCharset finds the encoding for a label, ignoring case and surrounding quotes. labels are resolved the way browsers resolve them, so ISO-8859-1 and US-ASCII are read as windows-1252, since feeds labelled Latin-1 routinely contain its curly quotes and dashes
+func lookupCharset(label string) (charset, error) {
+	enc, err := htmlindex.Get(strings.Trim(strings.TrimSpace(label), `"'`))
+	if err != nil {
+		return charset{}, fmt.Errorf("unsupported charset %q", label)
+	}
+	name, err := htmlindex.Name(enc)
+	if err != nil {
+		return charset{}, fmt.Errorf("unsupported charset %q", label)
+	}
+	if name == "utf-8" {
+		return charset{name: name}, nil
+	}
+	return charset{name: name, enc: enc}, nil
+}
+
+// NewUTF8Reader transcodes r from the charset named by label to UTF-8. it supports the same encodings as feeds, for documents other than feeds such as the article pages posts link to
+func NewUTF8Reader(r io.Reader, label string) (io.Reader, error) {
+	cs, err := lookupCharset(label)
+	if err != nil {
+		return nil, err
+	}
+	if cs.enc == nil {
+		return r, nil
+	}
+	return transform.NewReader(r, cs.enc.NewDecoder()), nil
+}
@@ -0,0 +1,169 @@
+package feed
+
+import (
+	"io"
+	"strings"
+	"testing"
+	"unicode/utf16"
+)
+
+// utf16Bytes encodes s as UTF-16 in the given byte order, with a byte order mark when bom is set
+func utf16Bytes(s string, bigEndian, bom bool) []byte {
+	units := utf16.Encode([]rune(s))
+	if bom {
+		units = append([]uint16{0xFEFF}, units...)
+	}
+	out := make([]byte, 0, 2*len(units))
+	for _, u := range units {
+		if bigEndian {
+			out = append(out, byte(u>>8), byte(u))
+		} else {
+			out = append(out, byte(u), byte(u>>8))
+		}
+	}
+	return out
+}
+
+func TestParseCharsets(t *testing.T) {
+	rss := func(decl, title string) string {
+		return decl + `<rss version="2.0"><channel><title>` + title + `</title></channel></rss>`
+	}
+	tests := []struct {
+		name         string
+		contentType  string
+		body         []byte
+		wantTitle    string
+		wantEncoding string
+	}{
+		{
+			name:         "utf-8 without a label",
+			body:         []byte(rss("", "Grüße")),
+			wantTitle:    "Grüße",
+			wantEncoding: "utf-8",
+		},
+		{
+			name:         "latin-1 header is read as windows-1252",
+			contentType:  "application/rss+xml; charset=ISO-8859-1",
+			body:         []byte(rss("", "caf\xe9 \x93quoted\x94")),
+			wantTitle:    "café “quoted”",
+			wantEncoding: "windows-1252",
+		},
+		{
+			name:         "iso-8859-2 declaration",
+			body:         []byte(rss(`<?xml version="1.0" encoding="iso-8859-2"?>`, "\xa3\xf3d\xbc")),
+			wantTitle:    "Łódź",
+			wantEncoding: "iso-8859-2",
+		},
+		{
+			name:         "windows-1250 header",
+			contentType:  "text/xml; charset=windows-1250",
+			body:         []byte(rss("", "P\xf8\xedli\x9a \x9elu\x9dou\xe8k\xfd")),
+			wantTitle:    "Příliš žluťoučký",
+			wantEncoding: "windows-1250",
+		},
+		{
+			name:         "koi8-r declaration",
+			body:         []byte(rss(`<?xml version="1.0" encoding="KOI8-R"?>`, "\xf0\xd2\xc9\xd7\xc5\xd4")),
+			wantTitle:    "Привет",
+			wantEncoding: "koi8-r",
+		},
+		{
+			name:         "shift_jis declaration",
+			body:         []byte(rss(`<?xml version="1.0" encoding="Shift_JIS"?>`, "\x93\xfa\x96\x7b")),
+			wantTitle:    "日本",
+			wantEncoding: "shift_jis",
+		},
+		{
+			name:         "utf-8 header contradicted by the bytes",
+			contentType:  "application/xml; charset=utf-8",
+			body:         []byte(rss(`<?xml version="1.0" encoding="windows-1251"?>`, "\xcf\xf0\xe8\xe2\xe5\xf2")),
+			wantTitle:    "Привет",
+			wantEncoding: "windows-1251",
+		},
+		{
+			name:         "unknown header label falls back to the declaration",
+			contentType:  "application/rss+xml; charset=x-made-up",
+			body:         []byte(rss(`<?xml version="1.0" encoding="iso-8859-15"?>`, "\xa4uro")),
+			wantTitle:    "€uro",
+			wantEncoding: "iso-8859-15",
+		},
+		{
+			name:         "unknown label on valid utf-8 falls back to utf-8",
+			contentType:  "application/rss+xml; charset=x-made-up",
+			body:         []byte(rss("", "Grüße")),
+			wantTitle:    "Grüße",
+			wantEncoding: "utf-8",
+		},
+		{
+			name:         "utf-16 declaration in ascii bytes is ignored",
+			body:         []byte(rss(`<?xml version="1.0" encoding="UTF-16"?>`, "plain")),
+			wantTitle:    "plain",
+			wantEncoding: "utf-8",
+		},
+		{
+			name:         "utf-8 byte order mark",
+			contentType:  "application/rss+xml; charset=windows-1252",
+			body:         []byte("\ufeff" + rss("", "Grüße")),
+			wantTitle:    "Grüße",
+			wantEncoding: "utf-8",
+		},
+		{
+			name:         "utf-16le with byte order mark",
+			body:         utf16Bytes(rss(`<?xml version="1.0" encoding="UTF-16"?>`, "Grüße"), false, true),
+			wantTitle:    "Grüße",
+			wantEncoding: "utf-16le",
+		},
+		{
+			name:         "utf-16be with byte order mark",
+			contentType:  "application/rss+xml; charset=utf-8",
+			body:         utf16Bytes(rss(`<?xml version="1.0" encoding="UTF-16"?>`, "日本"), true, true),
+			wantTitle:    "日本",
+			wantEncoding: "utf-16be",
+		},
+		{
+			name:         "utf-16le without byte order mark",
+			body:         utf16Bytes(rss(`<?xml version="1.0" encoding="UTF-16"?>`, "Grüße"), false, false),
+			wantTitle:    "Grüße",
+			wantEncoding: "utf-16le",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f, err := Parse(tt.body, tt.contentType)
+			if err != nil {
+				t.Fatalf("Parse: %v", err)
+			}
+			if f.Title != tt.wantTitle {
+				t.Errorf("Title = %q, want %q", f.Title, tt.wantTitle)
+			}
+			if f.Encoding != tt.wantEncoding {
+				t.Errorf("Encoding = %q, want %q", f.Encoding, tt.wantEncoding)
+			}
+		})
+	}
+}
+
+func TestParseUnknownCharset(t *testing.T) {
+	body := `<?xml version="1.0" encoding="x-made-up"?><rss version="2.0"><channel><title>caf` + "\xe9" + `</title></channel></rss>`
+	_, err := Parse([]byte(body), "")
+	if err == nil || !strings.Contains(err.Error(), "unsupported charset") {
+		t.Errorf("Parse error = %v, want an unsupported charset error", err)
+	}
+}
+
+func TestNewUTF8Reader(t *testing.T) {
+	r, err := NewUTF8Reader(strings.NewReader("\xa3\xf3d\xbc"), "ISO-8859-2")
+	if err != nil {
+		t.Fatalf("NewUTF8Reader: %v", err)
+	}
+	got, err := io.ReadAll(r)
+	if err != nil {
+		t.Fatalf("reading: %v", err)
+	}
+	if string(got) != "Łódź" {
+		t.Errorf("got %q, want %q", got, "Łódź")
+	}
+	if _, err := NewUTF8Reader(strings.NewReader(""), "x-made-up"); err == nil {
+		t.Error("NewUTF8Reader accepted an unknown charset")
+	}
+}
@@ -0,0 +1,191 @@
+package feed
+
+import (
+	"regexp"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// dateLayouts are tried in order after a date has been normalized by normalizeDate. They cover RFC 1123 for RSS 2.0, RFC 3339 for Atom and JSON Feed, W3C-DTF for RSS 1.0 dc:date, and the variants publishers produce in practice
+var dateLayouts = []string{
+	"2 Jan 2006 15:04:05 -0700",
+	"2 Jan 2006 15:04 -0700",
+	"2 Jan 06 15:04:05 -0700",
+	"2 Jan 06 15:04 -0700",
+	"2 Jan 2006 15:04:05",
+	"2 Jan 2006 15:04",
+	"2 Jan 2006",
+	"Jan 2 2006 15:04:05 -0700",
+	"Jan 2 2006 15:04 -0700",
+	"Jan 2 2006 15:04:05",
+	"Jan 2 2006 3:04:05 PM",
+	"Jan 2 2006 3:04 PM",
+	"Jan 2 2006",
+	"Jan 2 15:04:05 -0700 2006", // ANSI C / Unix date
+	"Jan 2 15:04:05 2006",
+	time.RFC3339Nano,
+	"2006-01-02T15:04Z07:00",
+	"2006-01-02T15:04:05.999999999",
+	"2006-01-02T15:04",
+	"2006-01-02 15:04:05.999999999Z07:00",
+	"2006-01-02 15:04:05.999999999 -0700",
+	"2006-01-02 15:04:05.999999999",
+	"2006-01-02 15:04Z07:00",
+	"2006-01-02 15:04 -0700",
+	"2006-01-02 15:04",
+	"2006-01-02",
+	"2006/01/02 15:04:05 -0700",
+	"2006/01/02 15:04:05",
+	"2006/01/02",
+	"02.01.2006 15:04:05 -0700",
+	"02.01.2006 15:04:05",
+	"02.01.2006",
+}
+
+// monthNames maps non-English month names and abbreviations to their English abbreviation. Keys are lower case without accents or trailing dots
+var monthNames = map[string]string{
+	// German
+	"januar": "Jan", "jan": "Jan", "jän": "Jan", "jänner": "Jan", "februar": "Feb", "feb": "Feb", "märz": "Mar", "mär": "Mar", "mrz": "Mar", "april": "Apr", "apr": "Apr", "mai": "May", "juni": "Jun", "jun": "Jun", "juli": "Jul", "jul": "Jul", "august": "Aug", "aug": "Aug", "september": "Sep", "sep": "Sep", "sept": "Sep", "oktober": "Oct", "okt": "Oct", "november": "Nov", "nov": "Nov", "dezember": "Dec", "dez": "Dec",
+	// French
+	"janvier": "Jan", "janv": "Jan", "février": "Feb", "fevrier": "Feb", "févr": "Feb", "fevr": "Feb", "mars": "Mar", "mar": "Mar", "avril": "Apr", "avr": "Apr", "juin": "Jun", "juillet": "Jul", "juil": "Jul", "août": "Aug", "aout": "Aug", "septembre": "Sep", "octobre": "Oct", "oct": "Oct", "novembre": "Nov", "décembre": "Dec", "decembre": "Dec", "déc": "Dec", "dec": "Dec",
+	// Spanish
+	"enero": "Jan", "ene": "Jan", "febrero": "Feb", "marzo": "Mar", "abril": "Apr", "abr": "Apr", "mayo": "May", "junio": "Jun", "julio": "Jul", "agosto": "Aug", "ago": "Aug", "septiembre": "Sep", "setiembre": "Sep", "set": "Sep", "octubre": "Oct", "noviembre": "Nov", "diciembre": "Dec", "dic": "Dec",
+	// Italian
+	"gennaio": "Jan", "gen": "Jan", "febbraio": "Feb", "aprile": "Apr", "maggio": "May", "mag": "May", "giugno": "Jun", "giu": "Jun", "luglio": "Jul", "lug": "Jul", "settembre": "Sep", "ottobre": "Oct", "ott": "Oct", "dicembre": "Dec",
+	// Portuguese
+	"janeiro": "Jan", "fevereiro": "Feb", "fev": "Feb", "março": "Mar", "marco": "Mar", "maio": "May", "junho": "Jun", "julho": "Jul", "setembro": "Sep", "outubro": "Oct", "out": "Oct", "novembro": "Nov", "dezembro": "Dec",
+	// Dutch
+	"januari": "Jan", "februari": "Feb", "maart": "Mar", "mrt": "Mar", "mei": "May", "augustus": "Aug",
+	// English long forms
+	"january": "Jan", "february": "Feb", "march": "Mar", "may": "May", "june": "Jun", "july": "Jul", "october": "Oct", "december": "Dec",
+}
+
+// zoneOffsets maps time zone abbreviations seen in feeds to numeric offsets. Go's time.Parse accepts abbreviations but treats unknown ones as UTC+0, which silently shifts the date
+var zoneOffsets = map[string]string{
+	"UT": "+0000", "UTC": "+0000", "GMT": "+0000", "Z": "+0000", "WET": "+0000", "WEST": "+0100", "BST": "+0100",
+	"CET": "+0100", "CEST": "+0200", "MET": "+0100", "MEST": "+0200", "EET": "+0200", "EEST": "+0300", "MSK": "+0300",
+	"IST": "+0530", "SGT": "+0800", "HKT": "+0800", "CST8": "+0800", "JST": "+0900", "KST": "+0900",
+	"AWST": "+0800", "ACST": "+0930", "ACDT": "+1030", "AEST": "+1000", "AEDT": "+1100", "NZST": "+1200", "NZDT": "+1300",
+	"EST": "-0500", "EDT": "-0400", "CST": "-0600", "CDT": "-0500", "MST": "-0700", "MDT": "-0600",
+	"PST": "-0800", "PDT": "-0700", "AKST": "-0900", "AKDT": "-0800", "HST": "-1000",
+	"AST": "-0400", "ADT": "-0300", "NST": "-0330", "NDT": "-0230", "BRT": "-0300", "ART": "-0300",
+}
+
+var (
+	// parenthesized comments such as "+0000 (UTC)"
+	dateComment = regexp.MustCompile(`\s*\([^)]*\)`)
+	// numeric offsets written with a colon after a space-separated time, e.g. "12:00:00 +01:00"
+	spacedColonOffset = regexp.MustCompile(`(\d) ([+-]\d\d):(\d\d)$`)
+	// "GMT+2" / "UTC-05:00" style offsets
+	prefixedOffset = regexp.MustCompile(`\b(?:GMT|UTC)([+-])(\d{1,2}):?(\d\d)?$`)
+	// ordinal day suffixes, e.g. "3rd"
+	ordinalDay = regexp.MustCompile(`\b(\d{1,2})(?:st|nd|rd|th)\b`)
+)
+
+// ParseDate parses a feed date in any of the formats seen in real-world feeds. It reports false if the date cannot be understood
+func ParseDate(s string) (time.Time, bool) {
+	s = normalizeDate(s)
+	if s == "" {
+		return time.Time{}, false
+	}
+	for _, layout := range dateLayouts {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t, true
+		}
+	}
+	return time.Time{}, false
+}
+
+// parseDate parses a feed date, returning the zero time if it cannot be understood
+func parseDate(s string) time.Time {
+	t, _ := ParseDate(s)
+	return t
+}
+
+// normalizeDate rewrites a date into the handful of shapes dateLayouts understands: no weekday, English month abbreviations, and numeric time zones
+func normalizeDate(s string) string {
+	s = dateComment.ReplaceAllString(s, "")
+	s = strings.ReplaceAll(s, ",", " ")
+	s = ordinalDay.ReplaceAllString(s, "$1")
+
+	fields := strings.Fields(s)
+	if len(fields) == 0 {
+		return ""
+	}
+
+	// a leading weekday in any language ("Mon", "Mittwoch", "mar.") is redundant. It is recognized by position, since some weekday abbreviations are also month abbreviations in other languages
+	if len(fields) >= 3 && isAlpha(fields[0]) {
+		if (isDigits(fields[1]) && monthAbbrev(fields[2]) != "") || monthAbbrev(fields[1]) != "" {
+			fields = fields[1:]
+		}
+	}
+
+	for i, f := range fields {
+		if en := monthAbbrev(f); en != "" {
+			fields[i] = en
+			continue
+		}
+		// zone abbreviations only appear after the time, never first
+		if off, ok := zoneOffsets[strings.ToUpper(f)]; ok && i > 0 {
+			fields[i] = off
+			continue
+		}
+		if f == "am" || f == "pm" {
+			fields[i] = strings.ToUpper(f)
+		}
+	}
+	s = strings.Join(fields, " ")
+
+	if m := prefixedOffset.FindStringSubmatch(s); m != nil {
+		hours := m[2]
+		if len(hours) == 1 {
+			hours = "0" + hours
+		}
+		minutes := m[3]
+		if minutes == "" {
+			minutes = "00"
+		}
+		s = strings.TrimSpace(s[:len(s)-len(m[0])]) + " " + m[1] + hours + minutes
+	}
+	return spacedColonOffset.ReplaceAllString(s, "$1 $2$3")
+}
+
+// monthAbbrev returns the English abbreviation for a month name in any supported language, or "" if s is not a month
+func monthAbbrev(s string) string {
+	key := strings.TrimSuffix(strings.ToLower(s), ".")
+	if en, ok := monthNames[key]; ok {
+		return en
+	}
+	if _, err := time.Parse("Jan", s); err == nil {
+		return s
+	}
+	return ""
+}
+
+// isAlpha reports whether s is a word, optionally followed by a dot
+func isAlpha(s string) bool {
+	s = strings.TrimSuffix(s, ".")
+	if s == "" {
+		return false
+	}
+	for _, r := range s {
+		if !unicode.IsLetter(r) {
+			return false
+		}
+	}
+	return true
+}
+
+// isDigits reports whether s is a non-empty run of ASCII digits
+func isDigits(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,66 @@
+package feed
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseDate(t *testing.T) {
+	utc := func(year int, month time.Month, day, hour, min, sec int) time.Time {
+		return time.Date(year, month, day, hour, min, sec, 0, time.UTC)
+	}
+	tests := []struct {
+		in   string
+		want time.Time
+	}{
+		// RFC 1123 and its variants
+		{"Tue, 02 Jan 2024 03:04:05 GMT", utc(2024, 1, 2, 3, 4, 5)},
+		{"Tue, 02 Jan 2024 03:04:05 +0000", utc(2024, 1, 2, 3, 4, 5)},
+		{"Tue, 2 Jan 2024 05:04:05 +0200", utc(2024, 1, 2, 3, 4, 5)},
+		{"Tue, 02 Jan 2024 03:04 GMT", utc(2024, 1, 2, 3, 4, 0)},
+		{"02 Jan 24 03:04:05 GMT", utc(2024, 1, 2, 3, 4, 5)},
+		{"Tuesday, 02 January 2024 03:04:05 GMT", utc(2024, 1, 2, 3, 4, 5)},
+		{"Mon, 01 Jan 2024 22:04:05 EST", utc(2024, 1, 2, 3, 4, 5)},
+		{"Tue, 02 Jan 2024 03:04:05 +0000 (UTC)", utc(2024, 1, 2, 3, 4, 5)},
+		{"Tue, 02 Jan 2024 04:04:05 +01:00", utc(2024, 1, 2, 3, 4, 5)},
+		{"Tue, 02 Jan 2024 05:04:05 GMT+2", utc(2024, 1, 2, 3, 4, 5)},
+		// RFC 3339 and W3C-DTF
+		{"2024-01-02T03:04:05Z", utc(2024, 1, 2, 3, 4, 5)},
+		{"2024-01-02T04:04:05+01:00", utc(2024, 1, 2, 3, 4, 5)},
+		{"2024-01-02T03:04:05.123Z", time.Date(2024, 1, 2, 3, 4, 5, 123000000, time.UTC)},
+		{"2024-01-02T03:04Z", utc(2024, 1, 2, 3, 4, 0)},
+		{"2024-01-02 03:04:05", utc(2024, 1, 2, 3, 4, 5)},
+		{"2024-01-02", utc(2024, 1, 2, 0, 0, 0)},
+		// other shapes seen in the wild
+		{"January 2nd, 2024", utc(2024, 1, 2, 0, 0, 0)},
+		{"Jan 2, 2024 3:04 PM", utc(2024, 1, 2, 15, 4, 0)},
+		{"Tue Jan 2 03:04:05 +0000 2024", utc(2024, 1, 2, 3, 4, 5)},
+		{"2024/01/02 03:04:05", utc(2024, 1, 2, 3, 4, 5)},
+		{"02.01.2024", utc(2024, 1, 2, 0, 0, 0)},
+		// non-English month and weekday names
+		{"Di, 02 Jan 2024 03:04:05 +0000", utc(2024, 1, 2, 3, 4, 5)},
+		{"mar., 02 janv. 2024 03:04:05 +0000", utc(2024, 1, 2, 3, 4, 5)},
+		{"2 März 2024", utc(2024, 3, 2, 0, 0, 0)},
+		{"martes, 02 enero 2024 03:04:05 GMT", utc(2024, 1, 2, 3, 4, 5)},
+		{"02 dicembre 2024", utc(2024, 12, 2, 0, 0, 0)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			got, ok := ParseDate(tt.in)
+			if !ok {
+				t.Fatalf("ParseDate(%q) failed", tt.in)
+			}
+			if !got.Equal(tt.want) {
+				t.Errorf("ParseDate(%q) = %v, want %v", tt.in, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseDateInvalid(t *testing.T) {
+	for _, in := range []string{"", "   ", "yesterday", "not a date at all", "2024-13-45", "32 Jan 2024"} {
+		if got, ok := ParseDate(in); ok {
+			t.Errorf("ParseDate(%q) = %v, want failure", in, got)
+		}
+	}
+}
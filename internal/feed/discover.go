@@ -0,0 +1,143 @@
+package feed
+
+import (
+	"bytes"
+	"html"
+	"mime"
+	"net/url"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// Candidate is a feed advertised by an HTML page
+type Candidate struct {
+	URL   string
+	Title string
+	Type  string
+}
+
+// CommonPaths are feed locations tried when a page does not advertise its feed
+var CommonPaths = []string{
+	"/feed",
+	"/feed/",
+	"/rss.xml",
+	"/atom.xml",
+	"/feed.xml",
+	"/index.xml",
+	"/rss",
+	"/feed.json",
+}
+
+// feedTypes ranks the media types of <link rel="alternate"> tags. lower is preferred
+var feedTypes = map[string]int{
+	"application/atom+xml":  0,
+	"application/rss+xml":   0,
+	"application/rdf+xml":   1,
+	"application/feed+json": 1,
+	"application/json":      2,
+}
+
+var (
+	linkTag  = regexp.MustCompile(`(?is)<link\b[^>]*>`)
+	baseTag  = regexp.MustCompile(`(?is)<base\b[^>]*>`)
+	htmlAttr = regexp.MustCompile(`(?s)([a-zA-Z_:][-a-zA-Z0-9_:.]*)\s*=\s*("[^"]*"|'[^']*'|[^\s"'>]+)`)
+)
+
+// IsHTML reports whether a response is an HTML page rather than a feed. servers label feeds text/html often enough that the body has the last word: a body that starts like JSON, like a feed's root element or like an XML document other than XHTML is not HTML, whatever the Content-Type says
+func IsHTML(contentType string, body []byte) bool {
+	head := bytes.TrimLeft(body, " \t\r\n\ufeff")
+	lower := bytes.ToLower(head)
+	if bytes.HasPrefix(lower, []byte("<!doctype html")) || bytes.HasPrefix(lower, []byte("<html")) {
+		return true
+	}
+	if bytes.HasPrefix(head, []byte("{")) {
+		return false
+	}
+	switch root := rootElement(head); {
+	case root == "rss" || root == "feed" || root == "RDF":
+		return false
+	case strings.EqualFold(root, "html"):
+		return true
+	case root != "" && bytes.HasPrefix(head, []byte("<?xml")):
+		return false
+	}
+
+	if mediaType, _, err := mime.ParseMediaType(contentType); err == nil {
+		switch mediaType {
+		case "text/html", "application/xhtml+xml":
+			return true
+		}
+	}
+	return false
+}
+
+// Discover returns the feeds an HTML page advertises with <link rel="alternate"> tags, best first. Relative links are resolved against pageURL
+func Discover(body []byte, pageURL string) []Candidate {
+	base, err := url.Parse(pageURL)
+	if err != nil {
+		return nil
+	}
+	if tag := baseTag.Find(body); tag != nil {
+		if href := parseAttrs(tag)["href"]; href != "" {
+			if b, err := base.Parse(href); err == nil {
+				base = b
+			}
+		}
+	}
+
+	var found []Candidate
+	seen := make(map[string]bool)
+	for _, tag := range linkTag.FindAll(body, -1) {
+		attrs := parseAttrs(tag)
+		if !slices.Contains(strings.Fields(strings.ToLower(attrs["rel"])), "alternate") {
+			continue
+		}
+		mediaType := strings.ToLower(strings.TrimSpace(attrs["type"]))
+		if _, ok := feedTypes[mediaType]; !ok || attrs["href"] == "" {
+			continue
+		}
+		u, err := base.Parse(strings.TrimSpace(attrs["href"]))
+		if err != nil || seen[u.String()] {
+			continue
+		}
+		seen[u.String()] = true
+		found = append(found, Candidate{URL: u.String(), Title: attrs["title"], Type: mediaType})
+	}
+
+	// keep document order among feeds of the same rank, sites list their main feed first
+	slices.SortStableFunc(found, func(a, b Candidate) int {
+		return feedTypes[a.Type] - feedTypes[b.Type]
+	})
+	return found
+}
+
+// CommonCandidates returns the CommonPaths resolved against the root of pageURL
+func CommonCandidates(pageURL string) []Candidate {
+	base, err := url.Parse(pageURL)
+	if err != nil {
+		return nil
+	}
+	var out []Candidate
+	for _, path := range CommonPaths {
+		u, err := base.Parse(path)
+		if err != nil {
+			continue
+		}
+		out = append(out, Candidate{URL: u.String()})
+	}
+	return out
+}
+
+// parseAttrs returns the attributes of an HTML tag, keyed by lower-case name, with entities decoded
+func parseAttrs(tag []byte) map[string]string {
+	attrs := make(map[string]string)
+	for _, m := range htmlAttr.FindAllSubmatch(tag, -1) {
+		value := string(m[2])
+		if len(value) >= 2 && (value[0] == '"' || value[0] == '\'') {
+			value = value[1 : len(value)-1]
+		}
+		attrs[strings.ToLower(string(m[1]))] = html.UnescapeString(value)
+	}
+	return attrs
+}
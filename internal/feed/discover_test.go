@@ -0,0 +1,31 @@
+package feed
+
+import "testing"
+
+func TestIsHTML(t *testing.T) {
+	tests := []struct {
+		name        string
+		contentType string
+		body        string
+		want        bool
+	}{
+		{"html page", "text/html; charset=utf-8", "<!DOCTYPE html><html><head></head></html>", true},
+		{"html page without content type", "", "\n  <html lang=\"en\"><head></head></html>", true},
+		{"xhtml page", "application/xhtml+xml", `<?xml version="1.0"?><html xmlns="http://www.w3.org/1999/xhtml"></html>`, true},
+		{"html fragment labelled html", "text/html", "<head><title>x</title></head>", true},
+		{"rss labelled html", "text/html", `<?xml version="1.0"?><rss version="2.0"><channel></channel></rss>`, false},
+		{"rss without declaration labelled html", "text/html", `<rss version="2.0"><channel></channel></rss>`, false},
+		{"atom labelled html", "text/html; charset=utf-8", "\ufeff<feed xmlns=\"http://www.w3.org/2005/Atom\"></feed>", false},
+		{"rdf labelled html", "text/html", `<rdf:RDF xmlns:rdf="http://www.w3.org/1999/02/22-rdf-syntax-ns#"></rdf:RDF>`, false},
+		{"other xml labelled html", "text/html", `<?xml version="1.0"?><rss:feed xmlns:rss="urn:x"></rss:feed>`, false},
+		{"json feed labelled html", "text/html", `{"version": "https://jsonfeed.org/version/1.1"}`, false},
+		{"rss", "application/rss+xml", `<rss version="2.0"></rss>`, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := IsHTML(tt.contentType, []byte(tt.body)); got != tt.want {
+				t.Errorf("IsHTML(%q, %q) = %v, want %v", tt.contentType, tt.body, got, tt.want)
+			}
+		})
+	}
+}
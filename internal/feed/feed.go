@@ -0,0 +1,178 @@
+// Package feed parses syndication documents (RSS 2.0, RSS 1.0/RDF, Atom 1.0
+// and JSON Feed) into a single format-neutral model.
+package feed
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"golang.org/x/text/transform"
+)
+
+// Feed is the format-neutral representation of a parsed feed
+type Feed struct {
+	Format      string // name of the parser that produced the feed, e.g. "rss" or "atom"
+	Title       string
+	Link        string // the site the feed belongs to, not the feed URL itself
+	Description string
+	Items       []Item
+	Truncated   bool     // parsing stopped at Limits.MaxItems, so later items were not read
+	Encoding    string   // character encoding the document was written in, e.g. "utf-8" or "windows-1251"
+	Warnings    []string // problems in the document that were repaired or skipped while parsing it
+
+	// publisher hints about how often the feed should be polled
+	TTL            time.Duration  // RSS <ttl>
+	UpdateInterval time.Duration  // syndication module sy:updatePeriod divided by sy:updateFrequency
+	SkipHours      []int          // hours (0-23, GMT) during which the feed should not be polled
+	SkipDays       []time.Weekday // days during which the feed should not be polled
+}
+
+// Item is a single entry of a feed, whatever format it came from
+type Item struct {
+	GUID       string // RSS guid, Atom id or JSON Feed id; a hash of link and title when the feed has none
+	Title      string
+	Link       string
+	Summary    string // short description, HTML allowed
+	Content    string // full body when the feed provides one, HTML allowed
+	Comments   string // URL of the page holding the item's comments
+	Authors    []Person
+	Categories []string
+	Enclosures []Enclosure
+	Episode    int       // podcast episode number, 0 when not given
+	Published  time.Time // zero when the feed gives no usable date
+	Updated    time.Time
+}
+
+// Person is an author or contributor of an item
+type Person struct {
+	Name  string
+	Email string
+	URL   string
+}
+
+// Enclosure is a media file attached to an item, e.g. a podcast episode
+type Enclosure struct {
+	URL      string
+	Type     string
+	Length   int64         // size in bytes, 0 when unknown
+	Duration time.Duration // playing time of audio and video, 0 when unknown
+}
+
+// Parser turns one feed format into a Feed
+type Parser interface {
+	// Name identifies the format, e.g. "rss"
+	Name() string
+	// Detect reports whether the document looks like this parser's format. head is the beginning of the document, not all of it
+	Detect(contentType string, head []byte) bool
+	// Parse reads the document from r and converts it into a Feed. when maxItems > 0 it stops reading after that many items and sets Truncated
+	Parse(r io.Reader, maxItems int) (*Feed, error)
+}
+
+// Limits bound the resources spent on a single document. zero means no limit
+type Limits struct {
+	MaxBytes int64 // larger documents fail with ErrTooLarge
+	MaxItems int   // items past the first MaxItems are not read
+}
+
+// sniffLen is how much of a document is buffered to detect its format
+const sniffLen = 16 << 10
+
+// ErrUnsupportedFormat is returned when no registered parser recognizes a document
+var ErrUnsupportedFormat = errors.New("unsupported feed format")
+
+// parsers are tried in order, so formats that can be sniffed cheaply come first
+var parsers = []Parser{
+	JSONParser{},
+	AtomParser{},
+	RDFParser{},
+	RSSParser{},
+}
+
+// Register adds a parser for an additional format. Registered parsers are tried after the built-in ones
+func Register(p Parser) {
+	parsers = append(parsers, p)
+}
+
+// Parse detects the format of body and parses it with the matching parser
+func Parse(body []byte, contentType string) (*Feed, error) {
+	return ParseReader(bytes.NewReader(body), contentType, Limits{})
+}
+
+// ParseReader is like Parse but streams the document from r, so memory stays bounded by the largest item rather than the whole document
+func ParseReader(r io.Reader, contentType string, limits Limits) (*Feed, error) {
+	if limits.MaxBytes > 0 {
+		r = newLimitedReader(r, limits.MaxBytes)
+	}
+	br := bufio.NewReaderSize(r, sniffLen)
+	head, err := br.Peek(sniffLen)
+	if err != nil && err != io.EOF && err != bufio.ErrBufferFull {
+		return nil, err
+	}
+
+	// the parsers only read UTF-8, so transcode legacy encodings as the document streams in
+	cs, err := detectCharset(contentType, head)
+	if err != nil {
+		return nil, err
+	}
+	if cs.enc != nil {
+		br = bufio.NewReaderSize(transform.NewReader(br, cs.enc.NewDecoder()), sniffLen)
+		head, err = br.Peek(sniffLen)
+		if err != nil && err != io.EOF && err != bufio.ErrBufferFull {
+			return nil, err
+		}
+	}
+	if bytes.HasPrefix(head, []byte("\ufeff")) {
+		br.Discard(len("\ufeff"))
+		head = head[len("\ufeff"):]
+	}
+
+	for _, p := range parsers {
+		if !p.Detect(contentType, head) {
+			continue
+		}
+		f, err := p.Parse(br, limits.MaxItems)
+		if err != nil {
+			return nil, fmt.Errorf("error parsing %s feed: %w", p.Name(), err)
+		}
+		f.Format = p.Name()
+		f.Encoding = cs.name
+		for i := range f.Items {
+			if f.Items[i].GUID == "" {
+				f.Items[i].GUID = fallbackGUID(f.Items[i])
+			}
+		}
+		return f, nil
+	}
+
+	if root := rootElement(head); root != "" {
+		return nil, fmt.Errorf("%w: <%s>", ErrUnsupportedFormat, root)
+	}
+	return nil, ErrUnsupportedFormat
+}
+
+// rootElement returns the local name of an XML document's root element, or "" if head is not the start of an XML document
+func rootElement(head []byte) string {
+	decoder := newXMLDecoder(bytes.NewReader(head))
+	for {
+		tok, err := decoder.Token()
+		if err != nil {
+			return ""
+		}
+		if start, ok := tok.(xml.StartElement); ok {
+			return start.Name.Local
+		}
+	}
+}
+
+// fallbackGUID derives a stable identity for items whose feed gives them no id
+func fallbackGUID(item Item) string {
+	sum := sha256.Sum256([]byte(item.Link + "\n" + item.Title))
+	return "sha256:" + hex.EncodeToString(sum[:])
+}
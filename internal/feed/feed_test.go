@@ -0,0 +1,258 @@
+package feed
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseFormats(t *testing.T) {
+	tests := []struct {
+		name        string
+		contentType string
+		doc         string
+		wantFormat  string
+		wantTitle   string
+		wantItem    string // title of the first item
+	}{
+		{
+			name:       "rss",
+			doc:        `<rss version="2.0"><channel><title>RSS</title><item><title>One</title></item></channel></rss>`,
+			wantFormat: "rss",
+			wantTitle:  "RSS",
+			wantItem:   "One",
+		},
+		{
+			name:       "atom",
+			doc:        `<feed xmlns="http://www.w3.org/2005/Atom"><title>Atom</title><entry><title>One</title></entry></feed>`,
+			wantFormat: "atom",
+			wantTitle:  "Atom",
+			wantItem:   "One",
+		},
+		{
+			name: "rdf",
+			doc: `<rdf:RDF xmlns:rdf="http://www.w3.org/1999/02/22-rdf-syntax-ns#" xmlns="http://purl.org/rss/1.0/">
+				<channel rdf:about="https://example.com/"><title>RDF</title></channel>
+				<item rdf:about="https://example.com/1"><title>One</title></item>
+			</rdf:RDF>`,
+			wantFormat: "rdf",
+			wantTitle:  "RDF",
+			wantItem:   "One",
+		},
+		{
+			name:        "json feed",
+			contentType: "application/feed+json",
+			doc:         `{"version": "https://jsonfeed.org/version/1.1", "title": "JSON", "items": [{"id": "1", "title": "One"}]}`,
+			wantFormat:  "json",
+			wantTitle:   "JSON",
+			wantItem:    "One",
+		},
+		{
+			name:       "json feed sniffed",
+			doc:        "\n" + `{"version": "https://jsonfeed.org/version/1", "title": "JSON", "items": [{"id": 1, "title": "One"}]}`,
+			wantFormat: "json",
+			wantTitle:  "JSON",
+			wantItem:   "One",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f, err := Parse([]byte(tt.doc), tt.contentType)
+			if err != nil {
+				t.Fatalf("Parse: %v", err)
+			}
+			if f.Format != tt.wantFormat {
+				t.Errorf("Format = %q, want %q", f.Format, tt.wantFormat)
+			}
+			if f.Title != tt.wantTitle {
+				t.Errorf("Title = %q, want %q", f.Title, tt.wantTitle)
+			}
+			if len(f.Items) != 1 || f.Items[0].Title != tt.wantItem {
+				t.Fatalf("Items = %+v, want one item titled %q", f.Items, tt.wantItem)
+			}
+			if f.Items[0].GUID == "" {
+				t.Error("item has no GUID")
+			}
+		})
+	}
+}
+
+func TestParseUnsupported(t *testing.T) {
+	tests := []struct {
+		name string
+		doc  string
+		want string
+	}{
+		{"unknown root", `<opml version="2.0"><body/></opml>`, "<opml>"},
+		{"not a document", "hello", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Parse([]byte(tt.doc), "")
+			if !errors.Is(err, ErrUnsupportedFormat) {
+				t.Fatalf("Parse error = %v, want ErrUnsupportedFormat", err)
+			}
+			if !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("Parse error = %q, want it to mention %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestFallbackGUID(t *testing.T) {
+	doc := `<rss version="2.0"><channel>
+		<item><title>One</title><link>https://example.com/1</link></item>
+		<item><title>Two</title><link>https://example.com/2</link></item>
+	</channel></rss>`
+	first, err := Parse([]byte(doc), "")
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	second, err := Parse([]byte(doc), "")
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	if first.Items[0].GUID == first.Items[1].GUID {
+		t.Errorf("items share the GUID %q", first.Items[0].GUID)
+	}
+	for i := range first.Items {
+		if first.Items[i].GUID != second.Items[i].GUID {
+			t.Errorf("GUID of item %d changed between parses: %q, %q", i, first.Items[i].GUID, second.Items[i].GUID)
+		}
+	}
+}
+
+// rssWithItems returns an RSS document with n items
+func rssWithItems(n int) string {
+	var b strings.Builder
+	b.WriteString(`<rss version="2.0"><channel><title>Big</title>`)
+	for i := range n {
+		fmt.Fprintf(&b, "<item><guid>%d</guid><title>Item %d</title><description>%s</description></item>", i, i, strings.Repeat("x", 100))
+	}
+	b.WriteString("</channel></rss>")
+	return b.String()
+}
+
+func TestParseReaderLimits(t *testing.T) {
+	doc := rssWithItems(50)
+	tests := []struct {
+		name          string
+		limits        Limits
+		wantItems     int
+		wantTruncated bool
+		wantErr       error
+	}{
+		{name: "no limits", limits: Limits{}, wantItems: 50},
+		{name: "item limit", limits: Limits{MaxItems: 10}, wantItems: 10, wantTruncated: true},
+		{name: "item limit above the item count", limits: Limits{MaxItems: 50}, wantItems: 50},
+		{name: "byte limit of exactly the document", limits: Limits{MaxBytes: int64(len(doc))}, wantItems: 50},
+		{name: "byte limit below the document", limits: Limits{MaxBytes: int64(len(doc)) - 1}, wantErr: ErrTooLarge},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f, err := ParseReader(strings.NewReader(doc), "", tt.limits)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("ParseReader error = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseReader: %v", err)
+			}
+			if len(f.Items) != tt.wantItems {
+				t.Errorf("got %d items, want %d", len(f.Items), tt.wantItems)
+			}
+			if f.Truncated != tt.wantTruncated {
+				t.Errorf("Truncated = %v, want %v", f.Truncated, tt.wantTruncated)
+			}
+		})
+	}
+}
+
+func TestParseReaderStopsBeforeByteLimit(t *testing.T) {
+	// the document is streamed, so a feed cut off by MaxItems is not read far enough to reach MaxBytes
+	doc := rssWithItems(500)
+	f, err := ParseReader(strings.NewReader(doc), "", Limits{MaxBytes: int64(len(doc)) / 2, MaxItems: 5})
+	if err != nil {
+		t.Fatalf("ParseReader: %v", err)
+	}
+	if len(f.Items) != 5 || !f.Truncated {
+		t.Errorf("got %d items, truncated %v, want 5 items, truncated", len(f.Items), f.Truncated)
+	}
+}
+
+func TestParseJSONFeedLimits(t *testing.T) {
+	var items []string
+	for i := range 20 {
+		items = append(items, fmt.Sprintf(`{"id": "%d", "title": "Item %d"}`, i, i))
+	}
+	doc := `{"version": "https://jsonfeed.org/version/1.1", "title": "JSON", "items": [` + strings.Join(items, ",") + `]}`
+	f, err := ParseReader(strings.NewReader(doc), "application/feed+json", Limits{MaxItems: 5})
+	if err != nil {
+		t.Fatalf("ParseReader: %v", err)
+	}
+	if len(f.Items) != 5 || !f.Truncated {
+		t.Errorf("got %d items, truncated %v, want 5 items, truncated", len(f.Items), f.Truncated)
+	}
+}
+
+func TestPollingHints(t *testing.T) {
+	tests := []struct {
+		name      string
+		doc       string
+		wantTTL   time.Duration
+		wantEvery time.Duration
+		wantHours []int
+		wantDays  []time.Weekday
+	}{
+		{
+			name: "rss ttl and skips",
+			doc: `<rss version="2.0"><channel><ttl>90</ttl>
+				<skipHours><hour>0</hour><hour>24</hour><hour>7</hour><hour>x</hour></skipHours>
+				<skipDays><day>Saturday</day><day>sunday</day><day>Someday</day></skipDays>
+			</channel></rss>`,
+			wantTTL:   90 * time.Minute,
+			wantHours: []int{0, 0, 7},
+			wantDays:  []time.Weekday{time.Saturday, time.Sunday},
+		},
+		{
+			name: "syndication module",
+			doc: `<rss version="2.0" xmlns:sy="http://purl.org/rss/1.0/modules/syndication/"><channel>
+				<sy:updatePeriod>daily</sy:updatePeriod><sy:updateFrequency>4</sy:updateFrequency>
+			</channel></rss>`,
+			wantEvery: 6 * time.Hour,
+		},
+		{
+			name:      "syndication module in atom",
+			doc:       `<feed xmlns="http://www.w3.org/2005/Atom" xmlns:sy="http://purl.org/rss/1.0/modules/syndication/"><sy:updatePeriod>hourly</sy:updatePeriod></feed>`,
+			wantEvery: time.Hour,
+		},
+		{
+			name: "invalid hints are ignored",
+			doc:  `<rss version="2.0"><channel><ttl>soon</ttl></channel></rss>`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f, err := Parse([]byte(tt.doc), "")
+			if err != nil {
+				t.Fatalf("Parse: %v", err)
+			}
+			if f.TTL != tt.wantTTL {
+				t.Errorf("TTL = %v, want %v", f.TTL, tt.wantTTL)
+			}
+			if f.UpdateInterval != tt.wantEvery {
+				t.Errorf("UpdateInterval = %v, want %v", f.UpdateInterval, tt.wantEvery)
+			}
+			if fmt.Sprint(f.SkipHours) != fmt.Sprint(tt.wantHours) {
+				t.Errorf("SkipHours = %v, want %v", f.SkipHours, tt.wantHours)
+			}
+			if fmt.Sprint(f.SkipDays) != fmt.Sprint(tt.wantDays) {
+				t.Errorf("SkipDays = %v, want %v", f.SkipDays, tt.wantDays)
+			}
+		})
+	}
+}
@@ -0,0 +1,74 @@
+package feed
+
+import (
+	"strconv"
+	"strings"
+	"time"
+)
+
+// syndication holds the RSS syndication module (sy:) elements, used by RSS 1.0 and some RSS 2.0 and Atom feeds
+type syndication struct {
+	UpdatePeriod    string `xml:"http://purl.org/rss/1.0/modules/syndication/ updatePeriod"`
+	UpdateFrequency string `xml:"http://purl.org/rss/1.0/modules/syndication/ updateFrequency"`
+}
+
+// interval converts the period and frequency into the time between updates, or 0 if the feed gives none
+func (sy syndication) interval() time.Duration {
+	var period time.Duration
+	switch strings.ToLower(strings.TrimSpace(sy.UpdatePeriod)) {
+	case "hourly":
+		period = time.Hour
+	case "daily":
+		period = 24 * time.Hour
+	case "weekly":
+		period = 7 * 24 * time.Hour
+	case "monthly":
+		period = 30 * 24 * time.Hour
+	case "yearly":
+		period = 365 * 24 * time.Hour
+	default:
+		return 0
+	}
+
+	frequency, err := strconv.Atoi(strings.TrimSpace(sy.UpdateFrequency))
+	if err != nil || frequency < 1 {
+		frequency = 1
+	}
+	return period / time.Duration(frequency)
+}
+
+// parseTTL converts an RSS <ttl> in minutes into a duration, or 0 if it is missing or invalid
+func parseTTL(s string) time.Duration {
+	minutes, err := strconv.Atoi(strings.TrimSpace(s))
+	if err != nil || minutes < 1 {
+		return 0
+	}
+	return time.Duration(minutes) * time.Minute
+}
+
+// parseSkipHours keeps the valid hours of an RSS <skipHours>
+func parseSkipHours(hours []string) []int {
+	var out []int
+	for _, h := range hours {
+		n, err := strconv.Atoi(strings.TrimSpace(h))
+		if err != nil || n < 0 || n > 24 {
+			continue
+		}
+		// some feeds number hours 1-24
+		out = append(out, n%24)
+	}
+	return out
+}
+
+// parseSkipDays keeps the valid days of an RSS <skipDays>
+func parseSkipDays(days []string) []time.Weekday {
+	var out []time.Weekday
+	for _, d := range days {
+		for wd := time.Sunday; wd <= time.Saturday; wd++ {
+			if strings.EqualFold(strings.TrimSpace(d), wd.String()) {
+				out = append(out, wd)
+			}
+		}
+	}
+	return out
+}
@@ -0,0 +1,221 @@
+package feed
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"html"
+	"io"
+	"mime"
+	"strings"
+	"time"
+)
+
+// jsonFeedItem is the wire format of a JSON Feed item
+type jsonFeedItem struct {
+	ID            jsonFeedID           `json:"id"`
+	URL           string               `json:"url"`
+	ExternalURL   string               `json:"external_url"`
+	Title         string               `json:"title"`
+	ContentHTML   string               `json:"content_html"`
+	ContentText   string               `json:"content_text"`
+	Summary       string               `json:"summary"`
+	DatePublished string               `json:"date_published"`
+	DateModified  string               `json:"date_modified"`
+	Authors       []jsonFeedAuthor     `json:"authors"`
+	Author        *jsonFeedAuthor      `json:"author"` // JSON Feed 1.0
+	Tags          []string             `json:"tags"`
+	Attachments   []jsonFeedAttachment `json:"attachments"`
+}
+
+// jsonFeedAuthor is a JSON Feed author object
+type jsonFeedAuthor struct {
+	Name string `json:"name"`
+	URL  string `json:"url"`
+}
+
+// jsonFeedAttachment is a JSON Feed attachment, the equivalent of an RSS enclosure
+type jsonFeedAttachment struct {
+	URL               string  `json:"url"`
+	MimeType          string  `json:"mime_type"`
+	SizeInBytes       int64   `json:"size_in_bytes"`
+	DurationInSeconds float64 `json:"duration_in_seconds"`
+}
+
+// jsonFeedID accepts both the string ids required by the spec and the numeric ids some publishers emit
+type jsonFeedID string
+
+func (id *jsonFeedID) UnmarshalJSON(b []byte) error {
+	var str string
+	if err := json.Unmarshal(b, &str); err == nil {
+		*id = jsonFeedID(str)
+		return nil
+	}
+	var num json.Number
+	if err := json.Unmarshal(b, &num); err != nil {
+		return fmt.Errorf("invalid item id: %s", b)
+	}
+	*id = jsonFeedID(num.String())
+	return nil
+}
+
+// JSONParser parses JSON Feed documents
+type JSONParser struct{}
+
+func (JSONParser) Name() string { return "json" }
+
+// Detect recognizes JSON Feed by content type or, for servers that send a generic type, by sniffing the body
+func (JSONParser) Detect(contentType string, head []byte) bool {
+	if mediaType, _, err := mime.ParseMediaType(contentType); err == nil {
+		switch mediaType {
+		case "application/feed+json", "application/json":
+			return true
+		}
+	}
+	trimmed := bytes.TrimLeft(head, " \t\r\n\ufeff")
+	return len(trimmed) > 0 && trimmed[0] == '{'
+}
+
+// Parse walks the top-level object key by key and decodes items one at a time, so the items array is never held in memory as a whole
+func (JSONParser) Parse(r io.Reader, maxItems int) (*Feed, error) {
+	d := json.NewDecoder(r)
+	if err := expectDelim(d, '{'); err != nil {
+		return nil, err
+	}
+
+	f := &Feed{}
+	version := ""
+	for d.More() {
+		tok, err := d.Token()
+		if err != nil {
+			return nil, err
+		}
+		key, _ := tok.(string)
+
+		switch key {
+		case "version":
+			err = d.Decode(&version)
+			if err == nil && !strings.HasPrefix(version, "https://jsonfeed.org/version/") {
+				return nil, fmt.Errorf("unsupported version: %q", version)
+			}
+		case "title":
+			err = d.Decode(&f.Title)
+		case "home_page_url":
+			err = d.Decode(&f.Link)
+		case "description":
+			err = d.Decode(&f.Description)
+		case "items":
+			err = decodeJSONItems(d, f, maxItems)
+		default:
+			var skip json.RawMessage
+			err = d.Decode(&skip)
+		}
+		if err != nil {
+			return nil, err
+		}
+		if f.Truncated {
+			break
+		}
+	}
+
+	// the version may come after the items, past the point where a truncated document stops being read
+	if version == "" && !f.Truncated {
+		return nil, fmt.Errorf("unsupported version: %q", version)
+	}
+	return f, nil
+}
+
+// decodeJSONItems decodes the items array into f, stopping once maxItems items have been read
+func decodeJSONItems(d *json.Decoder, f *Feed, maxItems int) error {
+	if err := expectDelim(d, '['); err != nil {
+		return err
+	}
+	for d.More() {
+		if maxItems > 0 && len(f.Items) == maxItems {
+			f.Truncated = true
+			return nil
+		}
+		var it jsonFeedItem
+		if err := d.Decode(&it); err != nil {
+			return err
+		}
+		f.Items = append(f.Items, it.item())
+	}
+	return expectDelim(d, ']')
+}
+
+// expectDelim reads the next token and checks that it is the delimiter want
+func expectDelim(d *json.Decoder, want json.Delim) error {
+	tok, err := d.Token()
+	if err != nil {
+		return err
+	}
+	if delim, ok := tok.(json.Delim); !ok || delim != want {
+		return fmt.Errorf("expected %q, found %v", want, tok)
+	}
+	return nil
+}
+
+// textToHTML escapes plain text and keeps its paragraphs and line breaks
+func textToHTML(text string) string {
+	text = strings.TrimSpace(strings.ReplaceAll(text, "\r\n", "\n"))
+	if text == "" {
+		return ""
+	}
+	var paragraphs []string
+	for _, p := range strings.Split(text, "\n\n") {
+		if p = strings.TrimSpace(p); p != "" {
+			paragraphs = append(paragraphs, "<p>"+strings.ReplaceAll(html.EscapeString(p), "\n", "<br>")+"</p>")
+		}
+	}
+	return strings.Join(paragraphs, "\n")
+}
+
+// item converts the wire format into an Item
+func (it jsonFeedItem) item() Item {
+	link := it.URL
+	if link == "" {
+		link = it.ExternalURL
+	}
+
+	// Item fields hold HTML, while content_text and summary are plain text
+	content := it.ContentHTML
+	if content == "" {
+		content = textToHTML(it.ContentText)
+	}
+
+	item := Item{
+		GUID:       string(it.ID),
+		Title:      it.Title,
+		Link:       link,
+		Summary:    html.EscapeString(it.Summary),
+		Content:    content,
+		Categories: trimAll(it.Tags),
+		Published:  parseDate(it.DatePublished),
+		Updated:    parseDate(it.DateModified),
+	}
+	if item.Published.IsZero() {
+		item.Published = item.Updated
+	}
+
+	authors := it.Authors
+	if len(authors) == 0 && it.Author != nil {
+		authors = []jsonFeedAuthor{*it.Author}
+	}
+	for _, a := range authors {
+		if a.Name != "" || a.URL != "" {
+			item.Authors = append(item.Authors, Person{Name: a.Name, URL: a.URL})
+		}
+	}
+	for _, a := range it.Attachments {
+		if a.URL != "" {
+			item.Enclosures = append(item.Enclosures, Enclosure{
+				URL:      a.URL,
+				Type:     a.MimeType,
+				Length:   a.SizeInBytes,
+				Duration: time.Duration(a.DurationInSeconds * float64(time.Second)).Round(time.Second),
+			})
+		}
+	}
+	return item
+}
@@ -0,0 +1,136 @@
+package feed
+
+import (
+	"encoding/xml"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// namespaces of the podcast extensions
+const (
+	nsITunes = "http://www.itunes.com/dtds/podcast-1.0.dtd"
+	nsMedia  = "http://search.yahoo.com/mrss/"
+)
+
+// podcast holds the iTunes podcast and Media RSS elements of an item. both RSS and Atom feeds use them
+type podcast struct {
+	Duration string         `xml:"http://www.itunes.com/dtds/podcast-1.0.dtd duration"`
+	Episode  string         `xml:"http://www.itunes.com/dtds/podcast-1.0.dtd episode"`
+	Media    []mediaContent `xml:"http://search.yahoo.com/mrss/ content"`
+	Groups   []mediaGroup   `xml:"http://search.yahoo.com/mrss/ group"`
+}
+
+// mediaGroup is the wire format of a Media RSS <media:group>, which bundles versions of the same file
+type mediaGroup struct {
+	Media []mediaContent `xml:"http://search.yahoo.com/mrss/ content"`
+}
+
+// mediaContent is the wire format of a Media RSS <media:content>
+type mediaContent struct {
+	URL      string `xml:"url,attr"`
+	Type     string `xml:"type,attr"`
+	Medium   string `xml:"medium,attr"`
+	FileSize string `xml:"fileSize,attr"`
+	Duration string `xml:"duration,attr"`
+}
+
+// decode reads an itunes: or media: element of an item into the matching field and skips any other element
+func (p *podcast) decode(d *xml.Decoder, start xml.StartElement) error {
+	switch start.Name {
+	case xml.Name{Space: nsITunes, Local: "duration"}:
+		return decodeString(d, start, &p.Duration)
+	case xml.Name{Space: nsITunes, Local: "episode"}:
+		return decodeString(d, start, &p.Episode)
+	case xml.Name{Space: nsMedia, Local: "content"}:
+		var m mediaContent
+		if err := d.DecodeElement(&m, &start); err != nil {
+			return err
+		}
+		p.Media = append(p.Media, m)
+		return nil
+	case xml.Name{Space: nsMedia, Local: "group"}:
+		var g mediaGroup
+		if err := d.DecodeElement(&g, &start); err != nil {
+			return err
+		}
+		p.Groups = append(p.Groups, g)
+		return nil
+	}
+	return d.Skip()
+}
+
+// apply adds the media files of the item to its enclosures, fills in what the <enclosure> elements leave out, and records the episode number
+func (p podcast) apply(item *Item) {
+	media := p.Media
+	for _, g := range p.Groups {
+		media = append(media, g.Media...)
+	}
+
+	for _, m := range media {
+		url := strings.TrimSpace(m.URL)
+		// blogs use media:content for article images, which are not attachments
+		if url == "" || m.Medium == "image" || strings.HasPrefix(m.Type, "image/") {
+			continue
+		}
+		length, _ := strconv.ParseInt(strings.TrimSpace(m.FileSize), 10, 64)
+		e := Enclosure{URL: url, Type: m.Type, Length: length, Duration: parseMediaDuration(m.Duration)}
+
+		i := indexEnclosure(item.Enclosures, url)
+		if i < 0 {
+			item.Enclosures = append(item.Enclosures, e)
+			continue
+		}
+		// the same file as an <enclosure>: only fill the gaps
+		if item.Enclosures[i].Type == "" {
+			item.Enclosures[i].Type = e.Type
+		}
+		if item.Enclosures[i].Length == 0 {
+			item.Enclosures[i].Length = e.Length
+		}
+		if item.Enclosures[i].Duration == 0 {
+			item.Enclosures[i].Duration = e.Duration
+		}
+	}
+
+	// itunes:duration describes the episode, so it applies to the enclosures that do not state their own
+	if d := parseMediaDuration(p.Duration); d > 0 {
+		for i := range item.Enclosures {
+			if item.Enclosures[i].Duration == 0 {
+				item.Enclosures[i].Duration = d
+			}
+		}
+	}
+
+	if n, err := strconv.Atoi(strings.TrimSpace(p.Episode)); err == nil && n > 0 {
+		item.Episode = n
+	}
+}
+
+// indexEnclosure returns the position of the enclosure with the given URL, or -1
+func indexEnclosure(enclosures []Enclosure, url string) int {
+	for i, e := range enclosures {
+		if e.URL == url {
+			return i
+		}
+	}
+	return -1
+}
+
+// parseMediaDuration reads the durations podcasts publish: seconds ("2712" or "2712.5"), "MM:SS" or "HH:MM:SS". it returns 0 when s is empty or invalid
+func parseMediaDuration(s string) time.Duration {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return 0
+	}
+
+	var total float64
+	for _, part := range strings.Split(s, ":") {
+		n, err := strconv.ParseFloat(part, 64)
+		if err != nil || n < 0 {
+			return 0
+		}
+		total = total*60 + n
+	}
+	return time.Duration(total * float64(time.Second)).Round(time.Second)
+}
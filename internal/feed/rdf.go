@@ -0,0 +1,99 @@
+package feed
+
+import (
+	"encoding/xml"
+	"io"
+	"strings"
+)
+
+// nsRSS1 is the namespace of the core RSS 1.0 elements
+const nsRSS1 = "http://purl.org/rss/1.0/"
+
+// rdfChannel is the wire format of an RSS 1.0 <channel>. unlike RSS 2.0, items are siblings of the channel rather than children of it. the fields are qualified with the RSS 1.0 namespace so that Dublin Core elements such as <dc:title> do not land in them
+type rdfChannel struct {
+	Title       string `xml:"http://purl.org/rss/1.0/ title"`
+	Link        string `xml:"http://purl.org/rss/1.0/ link"`
+	Description string `xml:"http://purl.org/rss/1.0/ description"`
+	syndication
+}
+
+// rdfItem is the wire format of an RSS 1.0 <item>, including its Dublin Core metadata
+type rdfItem struct {
+	About       string   `xml:"http://www.w3.org/1999/02/22-rdf-syntax-ns# about,attr"`
+	Title       string   `xml:"http://purl.org/rss/1.0/ title"`
+	Link        string   `xml:"http://purl.org/rss/1.0/ link"`
+	Description string   `xml:"http://purl.org/rss/1.0/ description"`
+	Content     string   `xml:"http://purl.org/rss/1.0/modules/content/ encoded"`
+	Date        string   `xml:"http://purl.org/dc/elements/1.1/ date"`
+	Creators    []string `xml:"http://purl.org/dc/elements/1.1/ creator"`
+	Subjects    []string `xml:"http://purl.org/dc/elements/1.1/ subject"`
+}
+
+// RDFParser parses RSS 1.0 (rdf:RDF) documents
+type RDFParser struct{}
+
+func (RDFParser) Name() string { return "rdf" }
+
+func (RDFParser) Detect(contentType string, head []byte) bool {
+	return rootElement(head) == "RDF"
+}
+
+func (RDFParser) Parse(r io.Reader, maxItems int) (*Feed, error) {
+	f := &Feed{}
+	var channel rdfChannel
+	warnings, err := walkXML(r, []string{"RDF"}, func(d *xml.Decoder, start xml.StartElement) error {
+		if start.Name.Space != nsRSS1 {
+			return d.Skip()
+		}
+
+		switch start.Name.Local {
+		case "item":
+			if maxItems > 0 && len(f.Items) == maxItems {
+				f.Truncated = true
+				return errStop
+			}
+			var it rdfItem
+			if err := d.DecodeElement(&it, &start); err != nil {
+				return err
+			}
+			f.Items = append(f.Items, it.item())
+			return nil
+		case "channel":
+			return d.DecodeElement(&channel, &start)
+		}
+		return d.Skip()
+	})
+	f.Warnings = warnings
+	if err != nil && !keepPartial(f, err) {
+		return nil, err
+	}
+
+	f.Title = unescapeText(strings.TrimSpace(channel.Title))
+	f.Link = strings.TrimSpace(channel.Link)
+	f.Description = unescapeText(channel.Description)
+	f.UpdateInterval = channel.interval()
+	return f, nil
+}
+
+// item converts the wire format into an Item
+func (it rdfItem) item() Item {
+	// rdf:about is the item's URI and usually matches its link
+	link := strings.TrimSpace(it.Link)
+	if link == "" {
+		link = it.About
+	}
+
+	item := Item{
+		GUID:       it.About,
+		Title:      unescapeText(strings.TrimSpace(it.Title)),
+		Link:       link,
+		Summary:    it.Description,
+		Content:    it.Content,
+		Categories: trimAll(it.Subjects),
+		Published:  parseDate(it.Date),
+	}
+	for _, name := range trimAll(it.Creators) {
+		item.Authors = append(item.Authors, Person{Name: name})
+	}
+	return item
+}
@@ -0,0 +1,48 @@
+package feed
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRDFDublinCore(t *testing.T) {
+	doc := `<?xml version="1.0"?>
+<rdf:RDF xmlns:rdf="http://www.w3.org/1999/02/22-rdf-syntax-ns#" xmlns="http://purl.org/rss/1.0/" xmlns:dc="http://purl.org/dc/elements/1.1/">
+<channel rdf:about="https://example.com/">
+	<title>Real</title>
+	<dc:title>DC</dc:title>
+	<link>https://example.com/</link>
+	<description>About the site</description>
+	<dc:description>DC description</dc:description>
+</channel>
+<item rdf:about="https://example.com/1">
+	<title>Real</title>
+	<dc:title>DC</dc:title>
+	<link>https://example.com/1</link>
+	<description>Summary</description>
+	<dc:description>DC description</dc:description>
+	<dc:date>2024-01-02T03:04:05Z</dc:date>
+	<dc:creator>Jane Doe</dc:creator>
+	<dc:subject>go</dc:subject>
+</item>
+</rdf:RDF>`
+	f, err := Parse([]byte(doc), "")
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	if f.Title != "Real" || f.Description != "About the site" {
+		t.Errorf("channel = %q, %q, want %q, %q", f.Title, f.Description, "Real", "About the site")
+	}
+	if len(f.Items) != 1 {
+		t.Fatalf("got %d items, want 1", len(f.Items))
+	}
+	assertItem(t, f.Items[0], Item{
+		GUID:       "https://example.com/1",
+		Title:      "Real",
+		Link:       "https://example.com/1",
+		Summary:    "Summary",
+		Published:  time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC),
+		Categories: []string{"go"},
+		Authors:    []Person{{Name: "Jane Doe"}},
+	})
+}
@@ -0,0 +1,243 @@
+package feed
+
+import (
+	"encoding/xml"
+	"html"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// namespaces of the RSS 2.0 item extensions gator reads
+const (
+	nsContent    = "http://purl.org/rss/1.0/modules/content/"
+	nsDublinCore = "http://purl.org/dc/elements/1.1/"
+)
+
+// rssNamespaces are the namespaces of the core RSS 2.0 elements: none, or one of the default namespaces some feeds declare on <rss>
+var rssNamespaces = map[string]bool{
+	"":                                      true,
+	"http://backend.userland.com/rss2":      true,
+	"http://backend.userland.com/rss":       true,
+	"http://blogs.law.harvard.edu/tech/rss": true,
+}
+
+// rssItem is the wire format of an RSS 2.0 <item>. it is decoded element by element, see UnmarshalXML
+type rssItem struct {
+	GUID        string
+	Title       string
+	Link        string
+	Description string
+	Content     string
+	PubDate     string
+	Comments    string
+	Author      string
+	Creators    []string
+	Categories  []string
+	Enclosures  []rssEnclosure
+	podcast
+}
+
+// UnmarshalXML decodes the children of an <item> one at a time. a struct tag without a namespace matches an element in any namespace, so decoding by tags would let <itunes:title> and <itunes:author> overwrite the RSS title and author
+func (it *rssItem) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
+	for {
+		tok, err := d.Token()
+		if err != nil {
+			return err
+		}
+		switch t := tok.(type) {
+		case xml.StartElement:
+			if err := it.decode(d, t); err != nil {
+				return err
+			}
+		case xml.EndElement:
+			return nil
+		}
+	}
+}
+
+// decode reads one child element of an <item> into the matching field
+func (it *rssItem) decode(d *xml.Decoder, start xml.StartElement) error {
+	switch space := start.Name.Space; {
+	case rssNamespaces[space]:
+	case space == nsContent:
+		if start.Name.Local == "encoded" {
+			return decodeString(d, start, &it.Content)
+		}
+		return d.Skip()
+	case space == nsDublinCore:
+		if start.Name.Local == "creator" {
+			return decodeAppend(d, start, &it.Creators)
+		}
+		return d.Skip()
+	default:
+		return it.podcast.decode(d, start)
+	}
+
+	switch start.Name.Local {
+	case "guid":
+		return decodeString(d, start, &it.GUID)
+	case "title":
+		return decodeString(d, start, &it.Title)
+	case "link":
+		return decodeString(d, start, &it.Link)
+	case "description":
+		return decodeString(d, start, &it.Description)
+	case "pubDate":
+		return decodeString(d, start, &it.PubDate)
+	case "comments":
+		return decodeString(d, start, &it.Comments)
+	case "author":
+		return decodeString(d, start, &it.Author)
+	case "category":
+		return decodeAppend(d, start, &it.Categories)
+	case "enclosure":
+		var e rssEnclosure
+		if err := d.DecodeElement(&e, &start); err != nil {
+			return err
+		}
+		it.Enclosures = append(it.Enclosures, e)
+		return nil
+	}
+	return d.Skip()
+}
+
+// rssEnclosure is the wire format of an RSS 2.0 <enclosure>
+type rssEnclosure struct {
+	URL    string `xml:"url,attr"`
+	Type   string `xml:"type,attr"`
+	Length string `xml:"length,attr"`
+}
+
+// RSSParser parses RSS 2.0 (and the compatible 0.9x) documents
+type RSSParser struct{}
+
+func (RSSParser) Name() string { return "rss" }
+
+func (RSSParser) Detect(contentType string, head []byte) bool {
+	return rootElement(head) == "rss"
+}
+
+func (RSSParser) Parse(r io.Reader, maxItems int) (*Feed, error) {
+	f := &Feed{}
+	var (
+		sy        syndication
+		ttl       string
+		skipHours struct {
+			Hours []string `xml:"hour"`
+		}
+		skipDays struct {
+			Days []string `xml:"day"`
+		}
+	)
+	warnings, err := walkXML(r, []string{"rss", "channel"}, func(d *xml.Decoder, start xml.StartElement) error {
+		switch space := start.Name.Space; {
+		case rssNamespaces[space]:
+		case space == nsSyndication:
+			return sy.decode(d, start)
+		default:
+			// extensions such as atom:link reuse the names of channel elements
+			return d.Skip()
+		}
+
+		switch start.Name.Local {
+		case "item":
+			if maxItems > 0 && len(f.Items) == maxItems {
+				f.Truncated = true
+				return errStop
+			}
+			var it rssItem
+			if err := d.DecodeElement(&it, &start); err != nil {
+				return err
+			}
+			f.Items = append(f.Items, it.item())
+			return nil
+		case "title":
+			return decodeString(d, start, &f.Title)
+		case "link":
+			return decodeString(d, start, &f.Link)
+		case "description":
+			return decodeString(d, start, &f.Description)
+		case "ttl":
+			return decodeString(d, start, &ttl)
+		case "skipHours":
+			return d.DecodeElement(&skipHours, &start)
+		case "skipDays":
+			return d.DecodeElement(&skipDays, &start)
+		}
+		return d.Skip()
+	})
+	f.Warnings = warnings
+	if err != nil && !keepPartial(f, err) {
+		return nil, err
+	}
+
+	f.Title = unescapeText(strings.TrimSpace(f.Title))
+	f.Description = unescapeText(f.Description)
+	f.Link = strings.TrimSpace(f.Link)
+	f.TTL = parseTTL(ttl)
+	f.UpdateInterval = sy.interval()
+	f.SkipHours = parseSkipHours(skipHours.Hours)
+	f.SkipDays = parseSkipDays(skipDays.Days)
+	return f, nil
+}
+
+// item converts the wire format into an Item
+func (it rssItem) item() Item {
+	item := Item{
+		GUID:       strings.TrimSpace(it.GUID),
+		Title:      unescapeText(strings.TrimSpace(it.Title)),
+		Link:       strings.TrimSpace(it.Link),
+		Summary:    it.Description,
+		Content:    it.Content,
+		Comments:   strings.TrimSpace(it.Comments),
+		Categories: trimAll(it.Categories),
+		Published:  parseDate(it.PubDate),
+	}
+	if it.Author != "" {
+		item.Authors = append(item.Authors, parseRSSAuthor(it.Author))
+	}
+	for _, name := range trimAll(it.Creators) {
+		item.Authors = append(item.Authors, Person{Name: name})
+	}
+	for _, e := range it.Enclosures {
+		if e.URL == "" {
+			continue
+		}
+		length, _ := strconv.ParseInt(strings.TrimSpace(e.Length), 10, 64)
+		item.Enclosures = append(item.Enclosures, Enclosure{URL: strings.TrimSpace(e.URL), Type: e.Type, Length: length})
+	}
+	it.podcast.apply(&item)
+	return item
+}
+
+// unescapeText decodes the entities left in an RSS title or channel description. these are plain text, but publishers routinely escape them twice, e.g. "&amp;amp;" or "&#8217;" inside CDATA, so the decoded text still holds entities. only RSS needs this: Atom titles are unescaped by their type and JSON Feed titles are never escaped
+func unescapeText(s string) string {
+	return html.UnescapeString(s)
+}
+
+// parseRSSAuthor splits the RSS "email (Name)" author convention into its parts
+func parseRSSAuthor(s string) Person {
+	s = strings.TrimSpace(s)
+	if open := strings.Index(s, "("); open > 0 && strings.HasSuffix(s, ")") {
+		return Person{
+			Name:  strings.TrimSpace(s[open+1 : len(s)-1]),
+			Email: strings.TrimSpace(s[:open]),
+		}
+	}
+	if strings.Contains(s, "@") && !strings.Contains(s, " ") {
+		return Person{Email: s}
+	}
+	return Person{Name: s}
+}
+
+// trimAll trims every string and drops the empty ones
+func trimAll(values []string) []string {
+	var out []string
+	for _, v := range values {
+		if v = strings.TrimSpace(v); v != "" {
+			out = append(out, v)
+		}
+	}
+	return out
+}
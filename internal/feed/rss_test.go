@@ -0,0 +1,194 @@
+package feed
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRSSItem(t *testing.T) {
+	pubDate := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	tests := []struct {
+		name string
+		item string
+		want Item
+	}{
+		{
+			name: "plain item",
+			item: `<item>
+				<guid>1</guid>
+				<title>Hello</title>
+				<link>https://example.com/1</link>
+				<description>&lt;p&gt;Summary&lt;/p&gt;</description>
+				<content:encoded><![CDATA[<p>Body</p>]]></content:encoded>
+				<comments>https://example.com/1#comments</comments>
+				<category>go</category>
+				<category> </category>
+				<pubDate>Tue, 02 Jan 2024 03:04:05 GMT</pubDate>
+			</item>`,
+			want: Item{
+				GUID:       "1",
+				Title:      "Hello",
+				Link:       "https://example.com/1",
+				Summary:    "<p>Summary</p>",
+				Content:    "<p>Body</p>",
+				Comments:   "https://example.com/1#comments",
+				Categories: []string{"go"},
+				Published:  pubDate,
+			},
+		},
+		{
+			name: "itunes title and author do not replace the RSS ones",
+			item: `<item>
+				<guid>2</guid>
+				<title>Episode 2: the long title</title>
+				<itunes:title>Short title</itunes:title>
+				<author>host@example.com (The Host)</author>
+				<itunes:author>Someone Else</itunes:author>
+				<pubDate>Tue, 02 Jan 2024 03:04:05 GMT</pubDate>
+			</item>`,
+			want: Item{
+				GUID:      "2",
+				Title:     "Episode 2: the long title",
+				Authors:   []Person{{Name: "The Host", Email: "host@example.com"}},
+				Published: pubDate,
+			},
+		},
+		{
+			name: "itunes elements come after the RSS ones",
+			item: `<item>
+				<itunes:title>Short title</itunes:title>
+				<itunes:author>Someone Else</itunes:author>
+				<guid>3</guid>
+				<title>Full title</title>
+				<pubDate>Tue, 02 Jan 2024 03:04:05 GMT</pubDate>
+			</item>`,
+			want: Item{GUID: "3", Title: "Full title", Published: pubDate},
+		},
+		{
+			name: "entities left in a title are decoded",
+			item: `<item>
+				<guid>6</guid>
+				<title>Fish &amp;amp; chips</title>
+				<pubDate>Tue, 02 Jan 2024 03:04:05 GMT</pubDate>
+			</item>`,
+			want: Item{GUID: "6", Title: "Fish & chips", Published: pubDate},
+		},
+		{
+			name: "entities inside a CDATA title are decoded",
+			item: `<item>
+				<guid>7</guid>
+				<title><![CDATA[It&#8217;s here]]></title>
+				<pubDate>Tue, 02 Jan 2024 03:04:05 GMT</pubDate>
+			</item>`,
+			want: Item{GUID: "7", Title: "It\u2019s here", Published: pubDate},
+		},
+		{
+			name: "dc creators",
+			item: `<item>
+				<guid>4</guid>
+				<dc:creator>Ada</dc:creator>
+				<dc:creator>Grace</dc:creator>
+				<pubDate>Tue, 02 Jan 2024 03:04:05 GMT</pubDate>
+			</item>`,
+			want: Item{GUID: "4", Authors: []Person{{Name: "Ada"}, {Name: "Grace"}}, Published: pubDate},
+		},
+		{
+			name: "enclosure completed by media content and itunes",
+			item: `<item>
+				<guid>5</guid>
+				<enclosure url="https://cdn.example.com/5.mp3" type="audio/mpeg" length="0"/>
+				<media:content url="https://cdn.example.com/5.mp3" fileSize="2048"/>
+				<media:content url="https://cdn.example.com/5.jpg" medium="image"/>
+				<media:group><media:content url="https://cdn.example.com/5.ogg" type="audio/ogg"/></media:group>
+				<itunes:duration>1:02:03</itunes:duration>
+				<itunes:episode>5</itunes:episode>
+				<pubDate>Tue, 02 Jan 2024 03:04:05 GMT</pubDate>
+			</item>`,
+			want: Item{
+				GUID:    "5",
+				Episode: 5,
+				Enclosures: []Enclosure{
+					{URL: "https://cdn.example.com/5.mp3", Type: "audio/mpeg", Length: 2048, Duration: time.Hour + 2*time.Minute + 3*time.Second},
+					{URL: "https://cdn.example.com/5.ogg", Type: "audio/ogg", Duration: time.Hour + 2*time.Minute + 3*time.Second},
+				},
+				Published: pubDate,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc := `<?xml version="1.0"?>
+<rss version="2.0"
+	xmlns:content="http://purl.org/rss/1.0/modules/content/"
+	xmlns:dc="http://purl.org/dc/elements/1.1/"
+	xmlns:itunes="http://www.itunes.com/dtds/podcast-1.0.dtd"
+	xmlns:media="http://search.yahoo.com/mrss/">
+<channel><title>Feed</title>` + tt.item + `</channel></rss>`
+			f, err := Parse([]byte(doc), "application/rss+xml")
+			if err != nil {
+				t.Fatalf("Parse: %v", err)
+			}
+			if len(f.Items) != 1 {
+				t.Fatalf("got %d items, want 1", len(f.Items))
+			}
+			assertItem(t, f.Items[0], tt.want)
+		})
+	}
+}
+
+func TestRSSChannel(t *testing.T) {
+	doc := `<rss version="2.0" xmlns:atom="http://www.w3.org/2005/Atom" xmlns:itunes="http://www.itunes.com/dtds/podcast-1.0.dtd">
+<channel>
+	<title> Channel </title>
+	<itunes:title>Show</itunes:title>
+	<atom:link href="https://example.com/feed.xml" rel="self"/>
+	<link>https://example.com/</link>
+	<ttl>60</ttl>
+</channel></rss>`
+	f, err := Parse([]byte(doc), "")
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	if f.Title != "Channel" {
+		t.Errorf("Title = %q, want %q", f.Title, "Channel")
+	}
+	if f.Link != "https://example.com/" {
+		t.Errorf("Link = %q, want %q", f.Link, "https://example.com/")
+	}
+	if f.TTL != time.Hour {
+		t.Errorf("TTL = %v, want %v", f.TTL, time.Hour)
+	}
+}
+
+func TestRSSDefaultNamespace(t *testing.T) {
+	// some feeds put the RSS elements in a namespace of their own, which must not be mistaken for an extension
+	doc := `<rss version="2.0" xmlns="http://backend.userland.com/rss2" xmlns:itunes="http://www.itunes.com/dtds/podcast-1.0.dtd">
+<channel>
+	<title>Channel</title>
+	<link>https://example.com/</link>
+	<item>
+		<guid>1</guid>
+		<title>Hello</title>
+		<itunes:title>Episode</itunes:title>
+		<link>https://example.com/1</link>
+		<pubDate>Tue, 02 Jan 2024 03:04:05 GMT</pubDate>
+	</item>
+</channel></rss>`
+	f, err := Parse([]byte(doc), "")
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	if f.Title != "Channel" || f.Link != "https://example.com/" {
+		t.Errorf("channel = %q, %q, want %q, %q", f.Title, f.Link, "Channel", "https://example.com/")
+	}
+	if len(f.Items) != 1 {
+		t.Fatalf("got %d items, want 1", len(f.Items))
+	}
+	assertItem(t, f.Items[0], Item{
+		GUID:      "1",
+		Title:     "Hello",
+		Link:      "https://example.com/1",
+		Published: time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC),
+	})
+}
@@ -0,0 +1,164 @@
+package feed
+
+import (
+	"bufio"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"unicode/utf8"
+)
+
+// entityRef matches a well-formed character or entity reference at the start of its input
+var entityRef = regexp.MustCompile(`^&(#[0-9]{1,7}|#[xX][0-9a-fA-F]{1,6}|[A-Za-z_][A-Za-z0-9._-]{0,31});`)
+
+// xmlEntities are the only named entities XML defines without a DTD
+var xmlEntities = map[string]bool{"amp": true, "lt": true, "gt": true, "quot": true, "apos": true}
+
+// maxEntityRef is the longest reference entityRef can match
+const maxEntityRef = 36
+
+// sanitizer repairs the mistakes that most often make feeds invalid XML while the document streams through it: ampersands that start no reference are escaped, characters XML forbids are dropped, invalid UTF-8 is replaced and stray byte order marks are removed. CDATA sections are passed through untouched. it counts every repair so they can be reported as warnings
+type sanitizer struct {
+	r       *bufio.Reader
+	out     []byte
+	pending []byte // sanitized bytes not returned yet
+	err     error
+	inCDATA bool
+	seenTag bool // a '<' has been read, so the prolog is over
+
+	ampersands   int
+	htmlEntities int
+	controls     int
+	invalidUTF8  int
+	byteOrder    int
+}
+
+func newSanitizer(r io.Reader) *sanitizer {
+	return &sanitizer{r: bufio.NewReader(r)}
+}
+
+func (s *sanitizer) Read(p []byte) (int, error) {
+	for len(s.pending) == 0 {
+		if s.err != nil {
+			return 0, s.err
+		}
+		s.fill()
+	}
+	n := copy(p, s.pending)
+	s.pending = s.pending[n:]
+	return n, nil
+}
+
+// fill sanitizes the next chunk of the document into pending
+func (s *sanitizer) fill() {
+	s.out = s.out[:0]
+	for len(s.out) < 4096 {
+		r, size, err := s.r.ReadRune()
+		if err != nil {
+			s.err = err
+			break
+		}
+
+		switch {
+		case r == utf8.RuneError && size == 1:
+			s.invalidUTF8++
+			s.out = utf8.AppendRune(s.out, utf8.RuneError)
+		case s.inCDATA:
+			s.out = utf8.AppendRune(s.out, r)
+			if r == ']' && s.consume("]>") {
+				s.out = append(s.out, "]>"...)
+				s.inCDATA = false
+			}
+		case r == '<':
+			s.seenTag = true
+			s.out = append(s.out, '<')
+			if s.consume("![CDATA[") {
+				s.out = append(s.out, "![CDATA["...)
+				s.inCDATA = true
+			}
+		case r == '&':
+			s.out = append(s.out, s.reference()...)
+		case r == '\ufeff' && !s.seenTag:
+			s.byteOrder++
+		case !isXMLChar(r):
+			s.controls++
+		default:
+			s.out = utf8.AppendRune(s.out, r)
+		}
+	}
+	s.pending = s.out
+}
+
+// consume skips prefix if the input continues with it
+func (s *sanitizer) consume(prefix string) bool {
+	next, _ := s.r.Peek(len(prefix))
+	if string(next) != prefix {
+		return false
+	}
+	s.r.Discard(len(prefix))
+	return true
+}
+
+// reference returns the replacement for an '&' that was just read: the ampersand itself when a reference follows it, "&amp;" otherwise
+func (s *sanitizer) reference() string {
+	next, _ := s.r.Peek(maxEntityRef - 1)
+	m := entityRef.FindSubmatch(append([]byte{'&'}, next...))
+	if m == nil {
+		s.ampersands++
+		return "&amp;"
+	}
+
+	name := string(m[1])
+	switch {
+	case name[0] == '#' || xmlEntities[name]:
+	case xml.HTMLEntity[name] != "":
+		// resolved by the decoder's HTML entity map
+		s.htmlEntities++
+	default:
+		// an unknown name, e.g. "AT&T;", is text rather than a reference
+		s.ampersands++
+		return "&amp;"
+	}
+	return "&"
+}
+
+// warnings describes the repairs made so far
+func (s *sanitizer) warnings() []string {
+	var out []string
+	if s.ampersands > 0 {
+		out = append(out, fmt.Sprintf("escaped %d bare ampersands", s.ampersands))
+	}
+	if s.htmlEntities > 0 {
+		out = append(out, fmt.Sprintf("resolved %d HTML entities that XML does not define", s.htmlEntities))
+	}
+	if s.controls > 0 {
+		out = append(out, fmt.Sprintf("removed %d characters not allowed in XML", s.controls))
+	}
+	if s.invalidUTF8 > 0 {
+		out = append(out, fmt.Sprintf("replaced %d invalid UTF-8 sequences", s.invalidUTF8))
+	}
+	if s.byteOrder > 0 {
+		out = append(out, "removed a misplaced byte order mark")
+	}
+	return out
+}
+
+// isXMLChar reports whether r may appear in an XML 1.0 document
+func isXMLChar(r rune) bool {
+	return r == '\t' || r == '\n' || r == '\r' ||
+		r >= 0x20 && r <= 0xD7FF ||
+		r >= 0xE000 && r <= 0xFFFD ||
+		r >= 0x10000 && r <= utf8.MaxRune
+}
+
+// keepPartial decides whether a feed that broke off with err is still worth keeping. when a syntax error comes after some items were read, the items are kept and the error becomes a warning
+func keepPartial(f *Feed, err error) bool {
+	var syntax *xml.SyntaxError
+	if !errors.As(err, &syntax) || len(f.Items) == 0 {
+		return false
+	}
+	f.Warnings = append(f.Warnings, fmt.Sprintf("ignored the rest of the document after %d items: %v", len(f.Items), err))
+	return true
+}
@@ -0,0 +1,142 @@
+package feed
+
+import (
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestSanitizer(t *testing.T) {
+	tests := []struct {
+		name         string
+		in           string
+		want         string
+		wantWarnings []string
+	}{
+		{
+			name: "valid xml is untouched",
+			in:   `<a href="x?a=1&amp;b=2">&lt;&#233;&#xE9;</a>`,
+			want: `<a href="x?a=1&amp;b=2">&lt;&#233;&#xE9;</a>`,
+		},
+		{
+			name:         "bare ampersands are escaped",
+			in:           "<t>Fish & chips, AT&T; R&D</t>",
+			want:         "<t>Fish &amp; chips, AT&amp;T; R&amp;D</t>",
+			wantWarnings: []string{"escaped 3 bare ampersands"},
+		},
+		{
+			name:         "html entities are kept for the decoder",
+			in:           "<t>a&nbsp;b&mdash;c</t>",
+			want:         "<t>a&nbsp;b&mdash;c</t>",
+			wantWarnings: []string{"resolved 2 HTML entities that XML does not define"},
+		},
+		{
+			name:         "characters xml forbids are dropped",
+			in:           "<t>a\x00b\x0bc\td</t>",
+			want:         "<t>abc\td</t>",
+			wantWarnings: []string{"removed 2 characters not allowed in XML"},
+		},
+		{
+			name:         "invalid utf-8 is replaced",
+			in:           "<t>caf\xe9</t>",
+			want:         "<t>caf�</t>",
+			wantWarnings: []string{"replaced 1 invalid UTF-8 sequences"},
+		},
+		{
+			name:         "byte order mark before the first tag is removed",
+			in:           "\n\ufeff<t>x</t>",
+			want:         "\n<t>x</t>",
+			wantWarnings: []string{"removed a misplaced byte order mark"},
+		},
+		{
+			name: "cdata is passed through",
+			in:   "<t><![CDATA[a & b \x00 ]] > ]]></t>",
+			want: "<t><![CDATA[a & b \x00 ]] > ]]></t>",
+		},
+		{
+			name:         "repairs resume after cdata",
+			in:           "<t><![CDATA[&]]>&</t>",
+			want:         "<t><![CDATA[&]]>&amp;</t>",
+			wantWarnings: []string{"escaped 1 bare ampersands"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newSanitizer(strings.NewReader(tt.in))
+			got, err := io.ReadAll(s)
+			if err != nil {
+				t.Fatalf("reading: %v", err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+			if strings.Join(s.warnings(), "; ") != strings.Join(tt.wantWarnings, "; ") {
+				t.Errorf("warnings = %q, want %q", s.warnings(), tt.wantWarnings)
+			}
+		})
+	}
+}
+
+func TestSanitizerLongInput(t *testing.T) {
+	// chunks are cut every 4096 runes, which must not split a reference
+	in := "<t>" + strings.Repeat("a", 4094) + "&amp; & &nbsp;" + strings.Repeat("b", 5000) + "</t>"
+	want := "<t>" + strings.Repeat("a", 4094) + "&amp; &amp; &nbsp;" + strings.Repeat("b", 5000) + "</t>"
+	got, err := io.ReadAll(newSanitizer(strings.NewReader(in)))
+	if err != nil {
+		t.Fatalf("reading: %v", err)
+	}
+	if string(got) != want {
+		t.Errorf("long input was not sanitized as a whole")
+	}
+}
+
+func TestParseRepairsBrokenFeeds(t *testing.T) {
+	tests := []struct {
+		name      string
+		doc       string
+		wantItems []string
+		wantWarn  string
+	}{
+		{
+			name:      "bare ampersand and html entity",
+			doc:       `<rss version="2.0"><channel><item><title>Fish & chips&nbsp;today</title></item></channel></rss>`,
+			wantItems: []string{"Fish & chips today"},
+			wantWarn:  "escaped 1 bare ampersands",
+		},
+		{
+			name: "items before a broken part are kept",
+			doc: `<rss version="2.0"><channel>
+				<item><title>One</title></item>
+				<item><title>Two</title></item>
+				<item><title>Three</title><description>1 < 2</description></item>
+			</channel></rss>`,
+			wantItems: []string{"One", "Two"},
+			wantWarn:  "ignored the rest of the document after 2 items",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f, err := Parse([]byte(tt.doc), "")
+			if err != nil {
+				t.Fatalf("Parse: %v", err)
+			}
+			var titles []string
+			for _, it := range f.Items {
+				titles = append(titles, it.Title)
+			}
+			if strings.Join(titles, "|") != strings.Join(tt.wantItems, "|") {
+				t.Errorf("items = %q, want %q", titles, tt.wantItems)
+			}
+			if !strings.Contains(strings.Join(f.Warnings, "; "), tt.wantWarn) {
+				t.Errorf("warnings = %q, want one containing %q", f.Warnings, tt.wantWarn)
+			}
+		})
+	}
+}
+
+func TestParseBrokenFeedWithoutItemsFails(t *testing.T) {
+	doc := `<rss version="2.0"><channel><title>Broken</title><item><title>1 < 2</title></item></channel></rss>`
+	if _, err := Parse([]byte(doc), ""); err == nil {
+		t.Error("Parse succeeded on a feed broken before its first item")
+	}
+}
@@ -0,0 +1,124 @@
+package feed
+
+import (
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// namespaces whose elements share local names with the core elements of a format
+const (
+	nsAtom        = "http://www.w3.org/2005/Atom"
+	nsSyndication = "http://purl.org/rss/1.0/modules/syndication/"
+)
+
+// ErrTooLarge is returned when a document is larger than Limits.MaxBytes
+var ErrTooLarge = errors.New("feed too large")
+
+// errStop ends walkXML early without an error, e.g. once the item limit is reached
+var errStop = errors.New("stop walking")
+
+// limitedReader reads at most limit bytes from r and fails with ErrTooLarge if the document goes on
+type limitedReader struct {
+	r     io.Reader
+	limit int64
+	n     int64 // bytes left before the limit
+}
+
+func newLimitedReader(r io.Reader, limit int64) *limitedReader {
+	return &limitedReader{r: r, limit: limit, n: limit}
+}
+
+func (l *limitedReader) Read(p []byte) (int, error) {
+	if l.n <= 0 {
+		// probe for one more byte to tell a document of exactly limit bytes from a longer one
+		var probe [1]byte
+		n, err := l.r.Read(probe[:])
+		if n > 0 {
+			return 0, fmt.Errorf("%w: larger than %d bytes", ErrTooLarge, l.limit)
+		}
+		return 0, err
+	}
+	if int64(len(p)) > l.n {
+		p = p[:l.n]
+	}
+	n, err := l.r.Read(p)
+	l.n -= int64(n)
+	return n, err
+}
+
+// newXMLDecoder returns a lenient decoder for a document ParseReader has already transcoded to UTF-8. the encoding in its XML declaration no longer describes the bytes, so it is ignored. HTML entities such as &nbsp; are resolved, and unknown entities and other slips that are not worth losing the feed over are tolerated
+func newXMLDecoder(r io.Reader) *xml.Decoder {
+	d := xml.NewDecoder(r)
+	d.CharsetReader = func(_ string, input io.Reader) (io.Reader, error) {
+		return input, nil
+	}
+	d.Strict = false
+	d.Entity = xml.HTMLEntity
+	return d
+}
+
+// walkXML streams an XML document token by token. it descends through the elements named by container, e.g. rss then channel, and calls visit for each child of the innermost one. visit must consume the element, with DecodeElement or Skip, so only one child is held in memory at a time. everything outside the container is skipped. visit may return errStop to end the walk early. the document is sanitized on the way in, and the repairs made are returned as warnings
+func walkXML(r io.Reader, container []string, visit func(d *xml.Decoder, start xml.StartElement) error) ([]string, error) {
+	clean := newSanitizer(r)
+	d := newXMLDecoder(clean)
+	depth := 0 // number of container elements the decoder is inside
+	for {
+		tok, err := d.Token()
+		if err == io.EOF {
+			return clean.warnings(), nil
+		}
+		if err != nil {
+			return clean.warnings(), err
+		}
+
+		switch t := tok.(type) {
+		case xml.StartElement:
+			switch {
+			case depth == len(container):
+				err = visit(d, t)
+			case t.Name.Local == container[depth]:
+				depth++
+				continue
+			default:
+				err = d.Skip()
+			}
+			if err == errStop {
+				return clean.warnings(), nil
+			}
+			if err != nil {
+				return clean.warnings(), err
+			}
+		case xml.EndElement:
+			// every other element is consumed whole, so only container elements end here
+			depth--
+		}
+	}
+}
+
+// decodeString decodes the character data of the element into s
+func decodeString(d *xml.Decoder, start xml.StartElement, s *string) error {
+	return d.DecodeElement(s, &start)
+}
+
+// decodeAppend reads the text of an element and appends it to values
+func decodeAppend(d *xml.Decoder, start xml.StartElement, values *[]string) error {
+	var s string
+	if err := d.DecodeElement(&s, &start); err != nil {
+		return err
+	}
+	*values = append(*values, s)
+	return nil
+}
+
+// decode reads an sy: element into the matching field
+func (sy *syndication) decode(d *xml.Decoder, start xml.StartElement) error {
+	switch start.Name.Local {
+	case "updatePeriod":
+		return decodeString(d, start, &sy.UpdatePeriod)
+	case "updateFrequency":
+		return decodeString(d, start, &sy.UpdateFrequency)
+	}
+	return d.Skip()
+}
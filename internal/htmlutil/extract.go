@@ -0,0 +1,338 @@
+package htmlutil
+
+import (
+	"html"
+	"net/url"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// node is an element or a piece of text in a parsed page
+type node struct {
+	tag      string // "" for text
+	text     string // unescaped text of a text node
+	attrs    []attr
+	parent   *node
+	children []*node
+}
+
+// get returns the value of the attribute key, or "" if the element has none
+func (n *node) get(key string) string {
+	val, _ := n.attr(key)
+	return val
+}
+
+// attr returns the value of the attribute key and whether the element has it
+func (n *node) attr(key string) (string, bool) {
+	for _, a := range n.attrs {
+		if a.key == key {
+			return a.val, true
+		}
+	}
+	return "", false
+}
+
+// parseTree builds a tree from a whole page. it knows only the implied end tags articles depend on: a block element closes an open p, and list items, definition terms, table rows and cells close the open sibling of their kind. end tags of elements that are not open are ignored
+func parseTree(s string) *node {
+	root := &node{tag: "#root"}
+	cur := root
+	for _, t := range tokenize(s) {
+		switch t.typ {
+		case textToken:
+			text := t.data
+			if !t.raw {
+				text = html.UnescapeString(text)
+			}
+			cur.children = append(cur.children, &node{text: text, parent: cur})
+		case startTagToken, selfClosingTagToken:
+			cur = closeImplied(cur, t.data)
+			n := &node{tag: t.data, attrs: t.attrs, parent: cur}
+			cur.children = append(cur.children, n)
+			if t.typ == startTagToken && !voidElements[t.data] {
+				cur = n
+			}
+		case endTagToken:
+			for n := cur; n != root; n = n.parent {
+				if n.tag == t.data {
+					cur = n.parent
+					break
+				}
+			}
+		}
+	}
+	return root
+}
+
+// impliedEnds maps elements to the open element they close, and the elements that stop the search for it
+var impliedEnds = map[string]struct {
+	closes string
+	stop   []string
+}{
+	"li": {"li", []string{"ul", "ol"}},
+	"dt": {"dd", []string{"dl"}},
+	"dd": {"dt", []string{"dl"}},
+	"tr": {"tr", []string{"table", "tbody", "thead", "tfoot"}},
+	"td": {"td", []string{"tr", "table"}},
+	"th": {"th", []string{"tr", "table"}},
+}
+
+// closeImplied returns the element a new tag goes into, closing the elements the tag ends implicitly
+func closeImplied(cur *node, tag string) *node {
+	if cur.tag == "p" && (blockElements[tag] || tag == "ul" || tag == "ol" || tag == "pre" || tag == "blockquote" || tag == "hr") {
+		return cur.parent
+	}
+	end, ok := impliedEnds[tag]
+	if !ok {
+		return cur
+	}
+	for n := cur; n.parent != nil; n = n.parent {
+		switch {
+		case n.tag == tag || n.tag == end.closes:
+			return n.parent
+		case slices.Contains(end.stop, n.tag):
+			return cur
+		}
+	}
+	return cur
+}
+
+// the class and id patterns readability uses to tell article content from page furniture
+var (
+	unlikelyCandidate = regexp.MustCompile(`(?i)-ad-|\bads?\b|banner|breadcrumb|combx|comment|community|cookie|disqus|extra|footer|gdpr|header|legends|menu|modal|nav|newsletter|pager|pagination|popup|promo|related|remark|replies|share|shoutbox|sidebar|skyscraper|social|sponsor|subscribe|widget`)
+	maybeCandidate    = regexp.MustCompile(`(?i)and|article|body|column|content|entry|main|post|shadow|story`)
+	positiveWeight    = regexp.MustCompile(`(?i)article|blog|body|content|entry|hentry|h-entry|main|page|post|story|text`)
+	negativeWeight    = regexp.MustCompile(`(?i)-ad-|banner|combx|comment|contact|foot|footnote|hidden|masthead|media|meta|outbrain|promo|related|scroll|share|shoutbox|sidebar|skyscraper|sponsor|shopping|tags|tool|widget`)
+)
+
+// prunedElements never hold article text
+var prunedElements = map[string]bool{
+	"aside": true, "button": true, "canvas": true, "embed": true, "footer": true, "form": true,
+	"head": true, "iframe": true, "input": true, "nav": true, "noscript": true, "object": true,
+	"script": true, "select": true, "style": true, "svg": true, "template": true, "textarea": true,
+}
+
+// ExtractArticle finds the main readable content of an article page with readability's heuristics and returns it as sanitized HTML, with links and images made absolute against pageURL. paragraphs are scored by their length and commas, their scores are credited to their parent and grandparent, and the best scoring container wins along with siblings that look like part of the same text. it returns "" when the page has no recognisable article
+func ExtractArticle(page, pageURL string) string {
+	root := parseTree(page)
+	prune(root)
+
+	scores := make(map[*node]float64)
+	var candidates []*node
+	for _, n := range paragraphs(root) {
+		text := innerText(n)
+		if len(text) < 25 {
+			continue
+		}
+		score := 1 + float64(strings.Count(text, ",")) + min(float64(len(text)/100), 3)
+		for level, ancestor := 0, n.parent; level < 2 && ancestor != nil && ancestor != root; level, ancestor = level+1, ancestor.parent {
+			if _, ok := scores[ancestor]; !ok {
+				scores[ancestor] = initialScore(ancestor)
+				candidates = append(candidates, ancestor)
+			}
+			if level == 0 {
+				scores[ancestor] += score
+			} else {
+				scores[ancestor] += score / 2
+			}
+		}
+	}
+
+	var top *node
+	for _, c := range candidates {
+		// containers made mostly of links are navigation, however much text they hold
+		scores[c] *= 1 - linkDensity(c)
+		if top == nil || scores[c] > scores[top] {
+			top = c
+		}
+	}
+	if top == nil {
+		return ""
+	}
+
+	// an article is often split over sibling containers, e.g. by an image or a pull quote
+	var parts []*node
+	threshold := max(10, scores[top]*0.2)
+	for _, sibling := range top.parent.children {
+		if sibling.tag == "" {
+			continue
+		}
+		keep := sibling == top
+		if score, ok := scores[sibling]; ok && score >= threshold {
+			keep = true
+		}
+		if sibling.tag == "p" {
+			text, density := innerText(sibling), linkDensity(sibling)
+			if len(text) > 80 && density < 0.25 || len(text) > 0 && density == 0 && strings.Contains(text, ". ") {
+				keep = true
+			}
+		}
+		if keep {
+			parts = append(parts, sibling)
+		}
+	}
+
+	base, _ := url.Parse(pageURL)
+	var b strings.Builder
+	for _, n := range parts {
+		writeNode(&b, n, base)
+	}
+	article := strings.TrimSpace(Sanitize(b.String()))
+	if strings.TrimSpace(ToText(article, 0)) == "" {
+		return ""
+	}
+	return article
+}
+
+// prune removes the elements that cannot be part of the article: scripts, forms and page furniture, hidden elements, and containers whose class or id looks like a sidebar, comments or ads
+func prune(n *node) {
+	kept := n.children[:0]
+	for _, c := range n.children {
+		if c.tag != "" && unlikely(c) {
+			continue
+		}
+		prune(c)
+		kept = append(kept, c)
+	}
+	n.children = kept
+}
+
+func unlikely(n *node) bool {
+	if prunedElements[n.tag] {
+		return true
+	}
+	if _, hidden := n.attr("hidden"); hidden || strings.Contains(strings.ReplaceAll(strings.ToLower(n.get("style")), " ", ""), "display:none") {
+		return true
+	}
+	switch n.tag {
+	case "html", "body", "article", "main":
+		return false
+	}
+	match := n.get("class") + " " + n.get("id")
+	return unlikelyCandidate.MatchString(match) && !maybeCandidate.MatchString(match)
+}
+
+// paragraphs returns the elements whose text is scored: p, pre and td, and divs that hold text but no blocks, which authors use as paragraphs
+func paragraphs(n *node) []*node {
+	var out []*node
+	for _, c := range n.children {
+		switch {
+		case c.tag == "p", c.tag == "pre", c.tag == "td":
+			out = append(out, c)
+		case c.tag == "div" && !hasBlockChild(c):
+			out = append(out, c)
+		default:
+			out = append(out, paragraphs(c)...)
+		}
+	}
+	return out
+}
+
+func hasBlockChild(n *node) bool {
+	for _, c := range n.children {
+		switch {
+		case blockElements[c.tag], c.tag == "ul", c.tag == "ol", c.tag == "pre", c.tag == "blockquote", c.tag == "img":
+			return true
+		case c.tag != "" && hasBlockChild(c):
+			return true
+		}
+	}
+	return false
+}
+
+// initialScore is the score a container starts with, from its tag and how its class and id read
+func initialScore(n *node) float64 {
+	score := 0.0
+	switch n.tag {
+	case "article":
+		score = 10
+	case "div":
+		score = 5
+	case "pre", "td", "blockquote":
+		score = 3
+	case "address", "ol", "ul", "dl", "dd", "dt", "li", "form":
+		score = -3
+	case "h1", "h2", "h3", "h4", "h5", "h6", "th":
+		score = -5
+	}
+	for _, s := range []string{n.get("class"), n.get("id")} {
+		if s == "" {
+			continue
+		}
+		if negativeWeight.MatchString(s) {
+			score -= 25
+		}
+		if positiveWeight.MatchString(s) {
+			score += 25
+		}
+	}
+	return score
+}
+
+// innerText returns the text of n with whitespace collapsed
+func innerText(n *node) string {
+	var b strings.Builder
+	var walk func(*node)
+	walk = func(n *node) {
+		if n.tag == "" {
+			b.WriteString(n.text)
+			b.WriteByte(' ')
+			return
+		}
+		for _, c := range n.children {
+			walk(c)
+		}
+	}
+	walk(n)
+	return strings.Join(strings.Fields(b.String()), " ")
+}
+
+// linkDensity is the share of the text of n that sits inside links
+func linkDensity(n *node) float64 {
+	total := len(innerText(n))
+	if total == 0 {
+		return 0
+	}
+	linked := 0
+	var walk func(*node)
+	walk = func(n *node) {
+		if n.tag == "a" {
+			linked += len(innerText(n))
+			return
+		}
+		for _, c := range n.children {
+			walk(c)
+		}
+	}
+	walk(n)
+	return float64(linked) / float64(total)
+}
+
+// writeNode serializes n back to HTML. URLs are resolved against base so the article still works offline, and lazy-loaded images get their real source
+func writeNode(b *strings.Builder, n *node, base *url.URL) {
+	if n.tag == "" {
+		b.WriteString(html.EscapeString(n.text))
+		return
+	}
+	b.WriteString("<" + n.tag)
+	for _, a := range n.attrs {
+		val := a.val
+		if n.tag == "img" && a.key == "src" && (val == "" || strings.HasPrefix(val, "data:")) {
+			val = n.get("data-src")
+		}
+		if urlAttributes[a.key] && base != nil {
+			if ref, err := url.Parse(strings.TrimSpace(val)); err == nil {
+				val = base.ResolveReference(ref).String()
+			}
+		}
+		b.WriteString(" " + a.key + `="` + html.EscapeString(val) + `"`)
+	}
+	b.WriteString(">")
+	if voidElements[n.tag] {
+		return
+	}
+	for _, c := range n.children {
+		writeNode(b, c, base)
+	}
+	b.WriteString("</" + n.tag + ">")
+}
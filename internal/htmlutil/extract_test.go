@@ -0,0 +1,84 @@
+package htmlutil
+
+import (
+	"strings"
+	"testing"
+)
+
+// articlePage wraps body in a page with the usual furniture around an article
+func articlePage(body string) string {
+	return `<!DOCTYPE html><html><head><title>Page</title><script>var tracking = 1;</script></head><body>
+<nav><a href="/">Home</a> <a href="/about">About</a></nav>
+<div class="sidebar"><p>Subscribe to our newsletter, it is great, really, truly, honestly.</p></div>
+` + body + `
+<div id="comments"><p>First comment, this is a long comment with, several, commas in it.</p></div>
+<footer><p>Copyright 2026, all rights reserved, by the publisher of this page.</p></footer>
+</body></html>`
+}
+
+const articleText = `<p>The first paragraph of the article is long enough to count, with a comma, and another one.</p>
+<p>The second paragraph goes on about the subject at length, adding detail, context and depth.</p>
+<p>The third paragraph wraps it up, with a conclusion that readers will find satisfying.</p>`
+
+func TestExtractArticle(t *testing.T) {
+	tests := []struct {
+		name    string
+		page    string
+		want    []string // substrings the article must contain
+		notWant []string // substrings it must not contain
+	}{
+		{
+			name:    "the article is kept and the furniture dropped",
+			page:    articlePage(`<div class="post-content">` + articleText + `</div>`),
+			want:    []string{"first paragraph", "second paragraph", "third paragraph"},
+			notWant: []string{"Home", "newsletter", "First comment", "Copyright", "tracking"},
+		},
+		{
+			name: "relative urls are resolved against the page",
+			page: articlePage(`<article>` + articleText + `<p>Read <a href="../more.html">more</a>, with an image, <img src="/img/a.png" alt="a">, and some words.</p></article>`),
+			want: []string{`href="https://example.com/more.html"`, `src="https://example.com/img/a.png"`},
+		},
+		{
+			name:    "lazy-loaded images get their real source",
+			page:    articlePage(`<article>` + articleText + `<p>An image, <img src="data:image/gif;base64,R0lGOD" data-src="/img/lazy.png" alt="lazy">, loads lazily here.</p></article>`),
+			want:    []string{`src="https://example.com/img/lazy.png"`},
+			notWant: []string{"data:image"},
+		},
+		{
+			name:    "the output is sanitized",
+			page:    articlePage(`<article>` + articleText + `<p onclick="steal()">A paragraph with a handler, which must not survive, at all.</p></article>`),
+			want:    []string{"<p>A paragraph with a handler"},
+			notWant: []string{"onclick", "steal"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := ExtractArticle(tt.page, "https://example.com/posts/one.html")
+			for _, s := range tt.want {
+				if !strings.Contains(got, s) {
+					t.Errorf("article does not contain %q:\n%s", s, got)
+				}
+			}
+			for _, s := range tt.notWant {
+				if strings.Contains(got, s) {
+					t.Errorf("article contains %q:\n%s", s, got)
+				}
+			}
+		})
+	}
+}
+
+func TestExtractArticleWithoutArticle(t *testing.T) {
+	pages := map[string]string{
+		"empty page":      "",
+		"only navigation": `<html><body><nav><a href="/">Home</a></nav><ul><li><a href="/a">A link to a page with a long title</a></li></ul></body></html>`,
+		"only short text": `<html><body><div><p>Too short.</p><p>Also short.</p></div></body></html>`,
+	}
+	for name, page := range pages {
+		t.Run(name, func(t *testing.T) {
+			if got := ExtractArticle(page, "https://example.com/"); got != "" {
+				t.Errorf("ExtractArticle = %q, want \"\"", got)
+			}
+		})
+	}
+}
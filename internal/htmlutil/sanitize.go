@@ -0,0 +1,191 @@
+package htmlutil
+
+import (
+	"html"
+	"slices"
+	"strings"
+)
+
+// allowedElements maps the elements Sanitize keeps to the attributes they may keep. everything else, including every style and event handler attribute, is removed
+var allowedElements = map[string][]string{
+	"a":          {"href", "title"},
+	"abbr":       {"title"},
+	"b":          nil,
+	"blockquote": {"cite"},
+	"br":         nil,
+	"caption":    nil,
+	"cite":       nil,
+	"code":       nil,
+	"dd":         nil,
+	"del":        nil,
+	"dl":         nil,
+	"dt":         nil,
+	"em":         nil,
+	"figcaption": nil,
+	"figure":     nil,
+	"h1":         nil,
+	"h2":         nil,
+	"h3":         nil,
+	"h4":         nil,
+	"h5":         nil,
+	"h6":         nil,
+	"hr":         nil,
+	"i":          nil,
+	"img":        {"src", "alt", "title", "width", "height"},
+	"ins":        nil,
+	"kbd":        nil,
+	"li":         nil,
+	"mark":       nil,
+	"ol":         {"start"},
+	"p":          nil,
+	"pre":        nil,
+	"q":          {"cite"},
+	"s":          nil,
+	"small":      nil,
+	"span":       nil,
+	"strong":     nil,
+	"sub":        nil,
+	"sup":        nil,
+	"table":      nil,
+	"tbody":      nil,
+	"td":         {"colspan", "rowspan"},
+	"tfoot":      nil,
+	"th":         {"colspan", "rowspan"},
+	"thead":      nil,
+	"time":       {"datetime"},
+	"tr":         nil,
+	"u":          nil,
+	"ul":         nil,
+}
+
+// droppedElements are removed together with their content, which is code, form controls or page furniture rather than text
+var droppedElements = map[string]bool{
+	"applet": true, "base": true, "button": true, "embed": true, "form": true, "frame": true,
+	"frameset": true, "head": true, "iframe": true, "link": true, "math": true, "meta": true,
+	"noscript": true, "object": true, "script": true, "select": true, "style": true, "svg": true,
+	"template": true, "textarea": true, "title": true,
+}
+
+// voidElements never have content or an end tag
+var voidElements = map[string]bool{
+	"area": true, "base": true, "br": true, "col": true, "embed": true, "frame": true, "hr": true,
+	"img": true, "input": true, "link": true, "meta": true, "param": true, "source": true,
+	"track": true, "wbr": true,
+}
+
+// urlAttributes hold URLs, which must pass safeURL
+var urlAttributes = map[string]bool{"href": true, "src": true, "cite": true}
+
+// Sanitize reduces an HTML fragment from a feed to an allowlisted subset that is safe to embed in a web page. elements outside the allowlist lose their markup but keep their text, while scripts, styles, frames and forms are dropped with their content. URLs must be http, https, mailto or relative. the result is rebuilt from scratch rather than patched, so all text and attribute values are escaped and every element is closed
+func Sanitize(s string) string {
+	var b strings.Builder
+	var open []string // allowed elements written but not closed yet
+	skip := 0         // depth inside dropped elements
+
+	for _, t := range tokenize(s) {
+		switch t.typ {
+		case textToken:
+			if skip > 0 {
+				continue
+			}
+			text := t.data
+			if !t.raw {
+				text = html.UnescapeString(text)
+			}
+			b.WriteString(html.EscapeString(text))
+		case startTagToken, selfClosingTagToken:
+			if droppedElements[t.data] {
+				if t.typ == startTagToken && !voidElements[t.data] {
+					skip++
+				}
+				continue
+			}
+			allowed, ok := allowedElements[t.data]
+			if skip > 0 || !ok {
+				continue
+			}
+			if t.data == "img" && !safeURL(t.get("src")) {
+				continue
+			}
+			writeStartTag(&b, t, allowed)
+			switch {
+			case voidElements[t.data]:
+			case t.typ == selfClosingTagToken:
+				b.WriteString("</" + t.data + ">")
+			default:
+				open = append(open, t.data)
+			}
+		case endTagToken:
+			if droppedElements[t.data] {
+				if skip > 0 {
+					skip--
+				}
+				continue
+			}
+			if skip > 0 {
+				continue
+			}
+			// close the elements left open inside this one too, so the output stays balanced. end tags of elements that are not open are ignored
+			for i := len(open) - 1; i >= 0; i-- {
+				if open[i] == t.data {
+					for j := len(open) - 1; j >= i; j-- {
+						b.WriteString("</" + open[j] + ">")
+					}
+					open = open[:i]
+					break
+				}
+			}
+		}
+	}
+
+	for i := len(open) - 1; i >= 0; i-- {
+		b.WriteString("</" + open[i] + ">")
+	}
+	return b.String()
+}
+
+// writeStartTag writes the start tag of t with only the allowed attributes. links also get a rel that keeps the linked page from reaching back into ours
+func writeStartTag(b *strings.Builder, t token, allowed []string) {
+	b.WriteString("<" + t.data)
+	seen := make(map[string]bool)
+	for _, a := range t.attrs {
+		if seen[a.key] || !slices.Contains(allowed, a.key) {
+			continue
+		}
+		seen[a.key] = true
+		if urlAttributes[a.key] && !safeURL(a.val) {
+			continue
+		}
+		b.WriteString(" " + a.key + `="` + html.EscapeString(a.val) + `"`)
+	}
+	if t.data == "a" {
+		b.WriteString(` rel="nofollow noopener noreferrer"`)
+	}
+	if t.typ == selfClosingTagToken && voidElements[t.data] {
+		b.WriteString(" /")
+	}
+	b.WriteString(">")
+}
+
+// safeURL reports whether a URL attribute can be kept: relative URLs and http, https and mailto URLs. browsers ignore whitespace and control characters inside a scheme, e.g. "java\tscript:", so they are removed before the check
+func safeURL(v string) bool {
+	clean := strings.Map(func(r rune) rune {
+		if r <= ' ' || r == 0x7f {
+			return -1
+		}
+		return r
+	}, v)
+	colon := strings.IndexByte(clean, ':')
+	if colon < 0 {
+		return true
+	}
+	if i := strings.IndexAny(clean, "/?#"); i >= 0 && i < colon {
+		// a relative URL with a colon further on, e.g. "/wiki/Talk:Feeds"
+		return true
+	}
+	switch strings.ToLower(clean[:colon]) {
+	case "http", "https", "mailto":
+		return true
+	}
+	return false
+}
@@ -0,0 +1,61 @@
+package htmlutil
+
+import "testing"
+
+func TestSanitize(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		want string
+	}{
+		{"allowed markup is kept", "<p>Hello <b>bold</b> <em>world</em></p>", "<p>Hello <b>bold</b> <em>world</em></p>"},
+		{"unknown elements keep their text", `<div class="x"><font color="red">text</font></div>`, "text"},
+		{"scripts and styles are dropped with their content", "a<script>alert(1)</script>b<style>p{color:red}</style>c", "abc"},
+		{"dropped elements hide nested markup", "<form><p>name</p><input></form>after", "after"},
+		{"event handlers and styles are removed", `<p onclick="x()" style="color:red">hi</p>`, "<p>hi</p>"},
+		{"links get a rel", `<a href="https://example.com/" target="_blank">x</a>`, `<a href="https://example.com/" rel="nofollow noopener noreferrer">x</a>`},
+		{"javascript urls are removed", `<a href="javascript:alert(1)">x</a>`, `<a rel="nofollow noopener noreferrer">x</a>`},
+		{"obfuscated schemes are removed", `<a href="java&#x09;script:alert(1)">x</a>`, `<a rel="nofollow noopener noreferrer">x</a>`},
+		{"relative urls with a colon are kept", `<a href="/wiki/Talk:Feeds">x</a>`, `<a href="/wiki/Talk:Feeds" rel="nofollow noopener noreferrer">x</a>`},
+		{"images with unsafe sources are dropped", `<img src="javascript:x"><img src="data:image/png;base64,AA">`, ""},
+		{"images keep their allowed attributes", `<img src="/a.png" alt="A" onerror="x()">`, `<img src="/a.png" alt="A">`},
+		{"duplicate attributes keep the first", `<img src="/a.png" src="javascript:x">`, `<img src="/a.png">`},
+		{"text is escaped", "1 &lt; 2 & 3 > 2", "1 &lt; 2 &amp; 3 &gt; 2"},
+		{"unclosed elements are closed", "<p><b>bold", "<p><b>bold</b></p>"},
+		{"misnested elements are closed in order", "<b><i>x</b>y</i>", "<b><i>x</i></b>y"},
+		{"stray end tags are ignored", "</p>text</div>", "text"},
+		{"self-closing void elements", "a<br/>b", "a<br />b"},
+		{"comments are dropped", "a<!-- secret -->b", "ab"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Sanitize(tt.in); got != tt.want {
+				t.Errorf("Sanitize(%q)\n got %q\nwant %q", tt.in, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSafeURL(t *testing.T) {
+	tests := []struct {
+		url  string
+		want bool
+	}{
+		{"https://example.com/", true},
+		{"http://example.com/", true},
+		{"mailto:me@example.com", true},
+		{"/relative/path", true},
+		{"page.html?q=a:b", true},
+		{"#top", true},
+		{"javascript:alert(1)", false},
+		{"JavaScript:alert(1)", false},
+		{" java\nscript:alert(1)", false},
+		{"data:text/html,<b>x</b>", false},
+		{"vbscript:msgbox", false},
+	}
+	for _, tt := range tests {
+		if got := safeURL(tt.url); got != tt.want {
+			t.Errorf("safeURL(%q) = %v, want %v", tt.url, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,371 @@
+package htmlutil
+
+import (
+	"fmt"
+	"html"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// skippedElements are not shown at all, their content is code or fallback for other media
+var skippedElements = map[string]bool{
+	"audio": true, "canvas": true, "embed": true, "head": true, "iframe": true, "noscript": true,
+	"object": true, "script": true, "select": true, "style": true, "svg": true, "template": true,
+	"title": true, "video": true,
+}
+
+// blockElements start and end a paragraph
+var blockElements = map[string]bool{
+	"address": true, "article": true, "aside": true, "caption": true, "dd": true, "details": true,
+	"div": true, "dl": true, "dt": true, "figcaption": true, "figure": true, "footer": true,
+	"h1": true, "h2": true, "h3": true, "h4": true, "h5": true, "h6": true, "header": true,
+	"main": true, "nav": true, "p": true, "section": true, "summary": true, "table": true, "tr": true,
+}
+
+// ToText renders an HTML fragment as plain text for a terminal. block elements become paragraphs, list items get bullets or numbers, blockquotes are prefixed with "> ", links are numbered and their URLs listed as footnotes at the end, images show their alt text, and scripts and styles are dropped. lines are wrapped at width columns; a width of 0 or less turns wrapping off
+func ToText(s string, width int) string {
+	r := &textRenderer{width: width}
+	for _, t := range tokenize(s) {
+		switch t.typ {
+		case textToken:
+			text := t.data
+			if !t.raw {
+				text = html.UnescapeString(text)
+			}
+			r.text(text)
+		case startTagToken:
+			r.start(t)
+		case selfClosingTagToken:
+			r.start(t)
+			if !voidElements[t.data] {
+				r.end(t.data)
+			}
+		case endTagToken:
+			r.end(t.data)
+		}
+	}
+	r.flush()
+
+	out := r.out.String()
+	if len(r.links) > 0 {
+		if out != "" {
+			out += "\n\n"
+		}
+		for i, link := range r.links {
+			if i > 0 {
+				out += "\n"
+			}
+			out += fmt.Sprintf("[%d] %s", i+1, link)
+		}
+	}
+	return out
+}
+
+// InlineText renders an HTML fragment as a single line of plain text, for titles marked up as HTML: tags are dropped, entities decoded and whitespace collapsed. scripts and styles are left out
+func InlineText(s string) string {
+	var b strings.Builder
+	skip := 0
+	for _, t := range tokenize(s) {
+		switch t.typ {
+		case textToken:
+			if skip > 0 {
+				continue
+			}
+			text := t.data
+			if !t.raw {
+				text = html.UnescapeString(text)
+			}
+			b.WriteString(text)
+		case startTagToken, selfClosingTagToken:
+			switch {
+			case skippedElements[t.data]:
+				if t.typ == startTagToken && !voidElements[t.data] {
+					skip++
+				}
+			case t.data == "br" || blockElements[t.data]:
+				b.WriteByte(' ')
+			}
+		case endTagToken:
+			switch {
+			case skippedElements[t.data]:
+				if skip > 0 {
+					skip--
+				}
+			case blockElements[t.data]:
+				b.WriteByte(' ')
+			}
+		}
+	}
+	return strings.Join(strings.Fields(b.String()), " ")
+}
+
+// list is an open ul or ol element
+type list struct {
+	ordered bool
+	n       int // number of the last item of an ordered list
+}
+
+// textRenderer collects the inline text of the current block and writes it out, wrapped and prefixed, whenever a block ends
+type textRenderer struct {
+	width int
+	out   strings.Builder
+	line  strings.Builder // inline text of the current block: words separated by single spaces, and '\n' for <br>
+
+	skip   int      // depth inside skipped elements
+	pre    int      // depth inside pre elements, where whitespace is kept
+	quote  int      // depth inside blockquotes
+	lists  []list   // open lists, innermost last
+	bullet string   // bullet of the list item the next block starts
+	hrefs  []string // hrefs of the open links, "" for links without a usable one
+	links  []string // footnote URLs, numbered from 1
+
+	lastInList bool // the last block written belonged to a list
+}
+
+func (r *textRenderer) start(t token) {
+	if skippedElements[t.data] {
+		if t.typ == startTagToken && !voidElements[t.data] {
+			r.skip++
+		}
+		return
+	}
+	if r.skip > 0 {
+		return
+	}
+
+	switch t.data {
+	case "br":
+		if r.pre > 0 {
+			r.line.WriteByte('\n')
+		} else {
+			r.trimSpace()
+			r.line.WriteByte('\n')
+		}
+	case "hr":
+		r.flush()
+		r.line.WriteString("---")
+		r.flush()
+	case "ul", "ol":
+		r.flush()
+		l := list{ordered: t.data == "ol"}
+		if n, err := strconv.Atoi(t.get("start")); err == nil {
+			l.n = n - 1
+		}
+		r.lists = append(r.lists, l)
+	case "li":
+		r.flush()
+		r.bullet = "- "
+		if len(r.lists) > 0 && r.lists[len(r.lists)-1].ordered {
+			r.lists[len(r.lists)-1].n++
+			r.bullet = fmt.Sprintf("%d. ", r.lists[len(r.lists)-1].n)
+		}
+	case "blockquote":
+		r.flush()
+		r.quote++
+	case "pre":
+		r.flush()
+		r.pre++
+	case "a":
+		href := t.get("href")
+		if href == "" || strings.HasPrefix(href, "#") || !safeURL(href) {
+			href = ""
+		}
+		r.hrefs = append(r.hrefs, href)
+	case "img":
+		if alt := strings.TrimSpace(t.get("alt")); alt != "" {
+			r.text(" [image: " + alt + "] ")
+		}
+	case "td", "th":
+		r.space()
+	default:
+		if blockElements[t.data] {
+			r.flush()
+		}
+	}
+}
+
+func (r *textRenderer) end(name string) {
+	if skippedElements[name] {
+		if r.skip > 0 {
+			r.skip--
+		}
+		return
+	}
+	if r.skip > 0 {
+		return
+	}
+
+	switch name {
+	case "ul", "ol":
+		r.flush()
+		if len(r.lists) > 0 {
+			r.lists = r.lists[:len(r.lists)-1]
+		}
+	case "li":
+		r.flush()
+		r.bullet = ""
+	case "blockquote":
+		r.flush()
+		if r.quote > 0 {
+			r.quote--
+		}
+	case "pre":
+		r.flush()
+		if r.pre > 0 {
+			r.pre--
+		}
+	case "a":
+		if len(r.hrefs) == 0 {
+			return
+		}
+		href := r.hrefs[len(r.hrefs)-1]
+		r.hrefs = r.hrefs[:len(r.hrefs)-1]
+		if href != "" {
+			r.space()
+			r.line.WriteString(fmt.Sprintf("[%d]", r.footnote(href)))
+		}
+	default:
+		if blockElements[name] {
+			r.flush()
+		}
+	}
+}
+
+// footnote returns the number of the footnote for href, adding one unless the URL was already linked
+func (r *textRenderer) footnote(href string) int {
+	for i, link := range r.links {
+		if link == href {
+			return i + 1
+		}
+	}
+	r.links = append(r.links, href)
+	return len(r.links)
+}
+
+// text adds character data to the current block, collapsing whitespace outside pre elements
+func (r *textRenderer) text(s string) {
+	if r.skip > 0 || s == "" {
+		return
+	}
+	if r.pre > 0 {
+		r.line.WriteString(s)
+		return
+	}
+	words := strings.FieldsFunc(s, func(c rune) bool { return c < utf8.RuneSelf && isSpace(byte(c)) })
+	if isSpace(s[0]) {
+		r.space()
+	}
+	for i, w := range words {
+		if i > 0 {
+			r.space()
+		}
+		r.line.WriteString(w)
+	}
+	if len(words) > 0 && isSpace(s[len(s)-1]) {
+		r.space()
+	}
+}
+
+// space separates the next word from the last one, unless the block or line has just begun
+func (r *textRenderer) space() {
+	line := r.line.String()
+	if line == "" || line[len(line)-1] == ' ' || line[len(line)-1] == '\n' {
+		return
+	}
+	r.line.WriteByte(' ')
+}
+
+// trimSpace removes a trailing space from the current block
+func (r *textRenderer) trimSpace() {
+	line := r.line.String()
+	if strings.HasSuffix(line, " ") {
+		r.line.Reset()
+		r.line.WriteString(line[:len(line)-1])
+	}
+}
+
+// flush writes the current block out as a paragraph. blocks are separated by a blank line, except for consecutive list items
+func (r *textRenderer) flush() {
+	text := r.line.String()
+	r.line.Reset()
+
+	var lines []string
+	if r.pre > 0 {
+		text = strings.Trim(text, "\n")
+		if strings.TrimSpace(text) == "" {
+			return
+		}
+		first, rest := r.prefixes()
+		for i, l := range strings.Split(text, "\n") {
+			if i == 0 {
+				lines = append(lines, first+l)
+			} else {
+				lines = append(lines, rest+l)
+			}
+		}
+	} else {
+		text = strings.TrimSpace(text)
+		if text == "" {
+			return
+		}
+		first, rest := r.prefixes()
+		lines = wrap(text, r.width, first, rest)
+	}
+	r.bullet = ""
+
+	inList := len(r.lists) > 0
+	if r.out.Len() > 0 {
+		if inList && r.lastInList {
+			r.out.WriteString("\n")
+		} else {
+			r.out.WriteString("\n\n")
+		}
+	}
+	r.lastInList = inList
+	r.out.WriteString(strings.Join(lines, "\n"))
+}
+
+// prefixes returns what the first and the following lines of the current block start with: the blockquote markers, the list indentation and, for the first block of a list item, its bullet
+func (r *textRenderer) prefixes() (string, string) {
+	quote := strings.Repeat("> ", r.quote)
+	indent := ""
+	if len(r.lists) > 1 {
+		indent = strings.Repeat("  ", len(r.lists)-1)
+	}
+	if r.bullet != "" {
+		return quote + indent + r.bullet, quote + indent + strings.Repeat(" ", len(r.bullet))
+	}
+	if len(r.lists) > 0 {
+		// a later paragraph of a list item lines up with the text after the bullet
+		indent += "  "
+	}
+	return quote + indent, quote + indent
+}
+
+// wrap breaks text into lines of at most width runes, prefixes included. a word longer than a line gets a line of its own. '\n' in text forces a line break
+func wrap(text string, width int, first, rest string) []string {
+	var lines []string
+	prefix := first
+	for _, segment := range strings.Split(text, "\n") {
+		line := prefix
+		empty := true
+		for _, word := range strings.Split(segment, " ") {
+			if word == "" {
+				continue
+			}
+			if !empty && width > 0 && utf8.RuneCountInString(line)+1+utf8.RuneCountInString(word) > width {
+				lines = append(lines, line)
+				line, empty = rest, true
+			}
+			if !empty {
+				line += " "
+			}
+			line += word
+			empty = false
+		}
+		lines = append(lines, strings.TrimRight(line, " "))
+		prefix = rest
+	}
+	return lines
+}
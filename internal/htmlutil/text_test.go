@@ -0,0 +1,63 @@
+package htmlutil
+
+import "testing"
+
+func TestToText(t *testing.T) {
+	tests := []struct {
+		name  string
+		in    string
+		width int
+		want  string
+	}{
+		{"plain text", "Hello,   world", 0, "Hello, world"},
+		{"paragraphs are separated by a blank line", "<p>One</p><p>Two</p>", 0, "One\n\nTwo"},
+		{"text around blocks is a paragraph of its own", "intro<div>body</div>outro", 0, "intro\n\nbody\n\noutro"},
+		{"line breaks", "one<br>two<br/>three", 0, "one\ntwo\nthree"},
+		{"entities are decoded", "<p>Fish &amp; chips&nbsp;&lt;3</p>", 0, "Fish & chips\u00a0<3"},
+		{"bulleted list", "<ul><li>one</li><li>two</li></ul>", 0, "- one\n- two"},
+		{"numbered list with a start", `<ol start="3"><li>three</li><li>four</li></ol>`, 0, "3. three\n4. four"},
+		{"nested lists are indented", "<ul><li>a<ul><li>b</li></ul></li></ul>", 0, "- a\n  - b"},
+		{"blockquote", "<p>said:</p><blockquote><p>one</p><p>two</p></blockquote>", 0, "said:\n\n> one\n\n> two"},
+		{"links become footnotes", `<p>See <a href="https://a.example/">this</a> and <a href="https://b.example/">that</a>.</p>`, 0, "See this [1] and that [2].\n\n[1] https://a.example/\n[2] https://b.example/"},
+		{"a url linked twice shares its footnote", `<a href="https://a.example/">x</a> <a href="https://a.example/">y</a>`, 0, "x [1] y [1]\n\n[1] https://a.example/"},
+		{"fragment and javascript links have no footnote", `<a href="#top">top</a> <a href="javascript:x()">run</a>`, 0, "top run"},
+		{"images show their alt text", `<p>A <img src="a.png" alt="cat"> here</p>`, 0, "A [image: cat] here"},
+		{"scripts and styles are dropped", "a<script>alert(1)</script> b<style>p{}</style>", 0, "a b"},
+		{"pre keeps whitespace", "<pre>  x := 1\n  y := 2</pre>", 0, "  x := 1\n  y := 2"},
+		{"horizontal rule", "one<hr>two", 0, "one\n\n---\n\ntwo"},
+		{"wrapping", "<p>the quick brown fox jumps over the lazy dog</p>", 20, "the quick brown fox\njumps over the lazy\ndog"},
+		{"wrapped list items hang under their bullet", "<ul><li>the quick brown fox jumps</li></ul>", 12, "- the quick\n  brown fox\n  jumps"},
+		{"wrapped quotes keep their marker", "<blockquote>the quick brown fox jumps</blockquote>", 12, "> the quick\n> brown fox\n> jumps"},
+		{"a word longer than the width gets its own line", "a https://example.com/a/long/path b", 10, "a\nhttps://example.com/a/long/path\nb"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ToText(tt.in, tt.width); got != tt.want {
+				t.Errorf("ToText(%q, %d)\n got %q\nwant %q", tt.in, tt.width, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestInlineText(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		want string
+	}{
+		{"plain text", "Hello, world", "Hello, world"},
+		{"tags are dropped", "A <em>very</em> <b>bold</b> title", "A very bold title"},
+		{"entities are decoded", "Fish &amp; chips &lt;3", "Fish & chips <3"},
+		{"escaped markup stays text", "Why <code>&lt;script&gt;</code> tags", "Why <script> tags"},
+		{"whitespace is collapsed", "  one\n\ttwo  ", "one two"},
+		{"blocks and breaks separate words", "<p>one</p><p>two</p>three<br>four", "one two three four"},
+		{"scripts and styles are left out", "a<script>alert(1)</script> b<style>p{}</style>", "a b"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := InlineText(tt.in); got != tt.want {
+				t.Errorf("InlineText(%q) = %q, want %q", tt.in, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,194 @@
+// Package htmlutil turns the HTML found in feeds into something safe to show:
+// plain text for the terminal, or an allowlisted subset of HTML for storage.
+package htmlutil
+
+import (
+	"html"
+	"strings"
+)
+
+type tokenType int
+
+const (
+	textToken tokenType = iota
+	startTagToken
+	endTagToken
+	selfClosingTagToken
+	commentToken // also doctypes and processing instructions, which are never shown
+)
+
+// attr is a tag attribute with its value already unescaped
+type attr struct {
+	key string
+	val string
+}
+
+// token is one piece of an HTML fragment
+type token struct {
+	typ   tokenType
+	data  string // escaped text, or the lower-case tag name
+	raw   bool   // text of a script or style element, where entities mean nothing
+	attrs []attr
+}
+
+// get returns the value of the attribute key, or "" if the tag has none
+func (t token) get(key string) string {
+	for _, a := range t.attrs {
+		if a.key == key {
+			return a.val
+		}
+	}
+	return ""
+}
+
+// rawTextElements hold text that runs until their end tag, whatever it looks like
+var rawTextElements = map[string]bool{"script": true, "style": true, "textarea": true, "title": true, "xmp": true}
+
+// tokenize splits an HTML fragment into tokens. it is as forgiving as browsers are: a '<' that cannot start a tag is text, an unterminated tag or comment runs to the end of the input, and the content of script and style elements is kept as one raw text token
+func tokenize(s string) []token {
+	var tokens []token
+	for len(s) > 0 {
+		i := indexTag(s)
+		if i < 0 {
+			tokens = append(tokens, token{typ: textToken, data: s})
+			break
+		}
+		if i > 0 {
+			tokens = append(tokens, token{typ: textToken, data: s[:i]})
+			s = s[i:]
+		}
+
+		switch {
+		case strings.HasPrefix(s, "<!--"):
+			end := strings.Index(s[4:], "-->")
+			if end < 0 {
+				s = ""
+			} else {
+				s = s[4+end+3:]
+			}
+			tokens = append(tokens, token{typ: commentToken})
+		case s[1] == '!' || s[1] == '?':
+			end := strings.IndexByte(s, '>')
+			if end < 0 {
+				s = ""
+			} else {
+				s = s[end+1:]
+			}
+			tokens = append(tokens, token{typ: commentToken})
+		default:
+			var tok token
+			tok, s = parseTag(s)
+			tokens = append(tokens, tok)
+			if tok.typ == startTagToken && rawTextElements[tok.data] {
+				end := strings.Index(strings.ToLower(s), "</"+tok.data)
+				if end < 0 {
+					end = len(s)
+				}
+				tokens = append(tokens, token{typ: textToken, data: s[:end], raw: true})
+				s = s[end:]
+			}
+		}
+	}
+	return tokens
+}
+
+// indexTag returns the position of the first '<' that starts a tag, comment or doctype, or -1
+func indexTag(s string) int {
+	offset := 0
+	for {
+		i := strings.IndexByte(s[offset:], '<')
+		if i < 0 || offset+i+1 >= len(s) {
+			return -1
+		}
+		i += offset
+		next := s[i+1]
+		switch {
+		case isLetter(next), next == '!', next == '?':
+			return i
+		case next == '/' && i+2 < len(s) && isLetter(s[i+2]):
+			return i
+		}
+		offset = i + 1
+	}
+}
+
+// parseTag reads the start or end tag at the beginning of s and returns it with the rest of the input
+func parseTag(s string) (token, string) {
+	tok := token{typ: startTagToken}
+	i := 1
+	if s[i] == '/' {
+		tok.typ = endTagToken
+		i++
+	}
+
+	start := i
+	for i < len(s) && !isSpace(s[i]) && s[i] != '/' && s[i] != '>' {
+		i++
+	}
+	tok.data = strings.ToLower(s[start:i])
+
+	for i < len(s) {
+		for i < len(s) && (isSpace(s[i]) || s[i] == '/' && !strings.HasPrefix(s[i:], "/>")) {
+			i++
+		}
+		if i >= len(s) {
+			break
+		}
+		if s[i] == '>' {
+			return tok, s[i+1:]
+		}
+		if strings.HasPrefix(s[i:], "/>") {
+			if tok.typ == startTagToken {
+				tok.typ = selfClosingTagToken
+			}
+			return tok, s[i+2:]
+		}
+
+		start := i
+		for i < len(s) && !isSpace(s[i]) && s[i] != '=' && s[i] != '>' && s[i] != '/' {
+			i++
+		}
+		key := strings.ToLower(s[start:i])
+		if key == "" {
+			// a stray character such as a lone quote
+			i++
+			continue
+		}
+
+		for i < len(s) && isSpace(s[i]) {
+			i++
+		}
+		val := ""
+		if i < len(s) && s[i] == '=' {
+			i++
+			for i < len(s) && isSpace(s[i]) {
+				i++
+			}
+			if i < len(s) && (s[i] == '"' || s[i] == '\'') {
+				quote := s[i]
+				end := strings.IndexByte(s[i+1:], quote)
+				if end < 0 {
+					val, i = s[i+1:], len(s)
+				} else {
+					val, i = s[i+1:i+1+end], i+1+end+1
+				}
+			} else {
+				start := i
+				for i < len(s) && !isSpace(s[i]) && s[i] != '>' {
+					i++
+				}
+				val = s[start:i]
+			}
+		}
+		tok.attrs = append(tok.attrs, attr{key: key, val: html.UnescapeString(val)})
+	}
+	return tok, ""
+}
+
+func isLetter(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z'
+}
+
+func isSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\n' || c == '\r' || c == '\f'
+}
@@ -0,0 +1,82 @@
+package htmlutil
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestTokenize(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		want []token
+	}{
+		{
+			name: "text only",
+			in:   "plain &amp; simple",
+			want: []token{{typ: textToken, data: "plain &amp; simple"}},
+		},
+		{
+			name: "tags are lower-cased",
+			in:   "<P>one</P>",
+			want: []token{{typ: startTagToken, data: "p"}, {typ: textToken, data: "one"}, {typ: endTagToken, data: "p"}},
+		},
+		{
+			name: "attributes in every quoting style",
+			in:   `<a HREF="/x?a=1&amp;b=2" title='a "quote"' rel=nofollow download>`,
+			want: []token{{typ: startTagToken, data: "a", attrs: []attr{
+				{key: "href", val: "/x?a=1&b=2"},
+				{key: "title", val: `a "quote"`},
+				{key: "rel", val: "nofollow"},
+				{key: "download"},
+			}}},
+		},
+		{
+			name: "self-closing tags",
+			in:   `<br/><img src="a.png" />`,
+			want: []token{{typ: selfClosingTagToken, data: "br"}, {typ: selfClosingTagToken, data: "img", attrs: []attr{{key: "src", val: "a.png"}}}},
+		},
+		{
+			name: "a '<' that starts no tag is text",
+			in:   "1 < 2 and a <3",
+			want: []token{{typ: textToken, data: "1 < 2 and a <3"}},
+		},
+		{
+			name: "comments, doctypes and processing instructions",
+			in:   "<!DOCTYPE html><?xml version=\"1.0\"?><!-- a <b> -->x",
+			want: []token{{typ: commentToken}, {typ: commentToken}, {typ: commentToken}, {typ: textToken, data: "x"}},
+		},
+		{
+			name: "an unterminated comment runs to the end",
+			in:   "a<!-- b <p>c",
+			want: []token{{typ: textToken, data: "a"}, {typ: commentToken}},
+		},
+		{
+			name: "script content is raw text",
+			in:   `<script>if (a < b && "</p>") {}</script>x`,
+			want: []token{
+				{typ: startTagToken, data: "script"},
+				{typ: textToken, data: `if (a < b && "</p>") {}`, raw: true},
+				{typ: endTagToken, data: "script"},
+				{typ: textToken, data: "x"},
+			},
+		},
+		{
+			name: "the end tag of raw text is matched without regard to case",
+			in:   "<style>p{}</STYLE>",
+			want: []token{{typ: startTagToken, data: "style"}, {typ: textToken, data: "p{}", raw: true}, {typ: endTagToken, data: "style"}},
+		},
+		{
+			name: "an unterminated tag runs to the end",
+			in:   `text<a href="x`,
+			want: []token{{typ: textToken, data: "text"}, {typ: startTagToken, data: "a", attrs: []attr{{key: "href", val: "x"}}}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tokenize(tt.in); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("tokenize(%q)\n got %+v\nwant %+v", tt.in, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,98 @@
+// Package schedule decides when a feed should be fetched next, based on how
+// often it actually publishes and on the polling hints the publisher gives.
+package schedule
+
+import (
+	"slices"
+	"time"
+
+	"github.com/jamesBoder/rss_aggreggator/internal/feed"
+)
+
+// Policy bounds the intervals the scheduler may choose
+type Policy struct {
+	Min     time.Duration // never poll a feed more often than this
+	Max     time.Duration // never leave a feed unpolled for longer than this
+	Default time.Duration // used when a feed has too few dated items to estimate its frequency
+}
+
+// DefaultPolicy is used by the aggregator unless overridden on the command line
+var DefaultPolicy = Policy{
+	Min:     5 * time.Minute,
+	Max:     24 * time.Hour,
+	Default: time.Hour,
+}
+
+// historySize is the number of most recent items used to estimate the posting frequency
+const historySize = 20
+
+// Interval returns how long to wait before fetching f again
+func (p Policy) Interval(f *feed.Feed) time.Duration {
+	interval := p.Default
+	if gap, ok := averageGap(f.Items); ok {
+		// poll about twice per expected post, so new posts show up within half a posting cycle
+		interval = gap / 2
+	}
+
+	// the publisher's hints are lower bounds: polling more often than asked is impolite and pointless
+	interval = max(interval, f.TTL, f.UpdateInterval)
+
+	return min(max(interval, p.Min), p.Max)
+}
+
+// Clamp bounds an interval, e.g. one stored from a previous fetch, to the policy
+func (p Policy) Clamp(interval time.Duration) time.Duration {
+	if interval <= 0 {
+		interval = p.Default
+	}
+	return min(max(interval, p.Min), p.Max)
+}
+
+// Backoff returns how long to wait before retrying a feed that has failed the given number of times in a row. The wait starts at the policy minimum and doubles with every failure, up to the maximum
+func (p Policy) Backoff(failures int) time.Duration {
+	wait := p.Min
+	for i := 1; i < failures && wait < p.Max; i++ {
+		wait *= 2
+	}
+	return min(wait, p.Max)
+}
+
+// Next returns the time f should be fetched next, after an interval from now, moved out of any hours or days the publisher asked to skip
+func Next(now time.Time, interval time.Duration, f *feed.Feed) time.Time {
+	next := now.Add(interval)
+	if f == nil || (len(f.SkipHours) == 0 && len(f.SkipDays) == 0) {
+		return next
+	}
+
+	// skipHours and skipDays are expressed in GMT. a feed that skips every hour is ignored after a week of searching
+	for range 7 * 24 {
+		utc := next.UTC()
+		if !slices.Contains(f.SkipHours, utc.Hour()) && !slices.Contains(f.SkipDays, utc.Weekday()) {
+			return next
+		}
+		next = utc.Truncate(time.Hour).Add(time.Hour)
+	}
+	return now.Add(interval)
+}
+
+// averageGap returns the mean time between the most recent dated items
+func averageGap(items []feed.Item) (time.Duration, bool) {
+	var dates []time.Time
+	for _, item := range items {
+		if !item.Published.IsZero() {
+			dates = append(dates, item.Published)
+		}
+	}
+	if len(dates) < 2 {
+		return 0, false
+	}
+
+	slices.SortFunc(dates, func(a, b time.Time) int { return b.Compare(a) })
+	dates = dates[:min(len(dates), historySize)]
+
+	span := dates[0].Sub(dates[len(dates)-1])
+	if span <= 0 {
+		return 0, false
+	}
+	return span / time.Duration(len(dates)-1), true
+}
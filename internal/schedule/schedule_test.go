@@ -0,0 +1,120 @@
+package schedule
+
+import (
+	"testing"
+	"time"
+
+	"github.com/jamesBoder/rss_aggreggator/internal/feed"
+)
+
+var testPolicy = Policy{Min: 5 * time.Minute, Max: 24 * time.Hour, Default: time.Hour}
+
+// itemsEvery returns n items published gap apart, newest first
+func itemsEvery(n int, gap time.Duration) []feed.Item {
+	start := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	items := make([]feed.Item, n)
+	for i := range items {
+		items[i].Published = start.Add(-time.Duration(i) * gap)
+	}
+	return items
+}
+
+func TestInterval(t *testing.T) {
+	tests := []struct {
+		name string
+		feed feed.Feed
+		want time.Duration
+	}{
+		{"no items uses the default", feed.Feed{}, time.Hour},
+		{"a single dated item uses the default", feed.Feed{Items: itemsEvery(1, time.Hour)}, time.Hour},
+		{"undated items use the default", feed.Feed{Items: make([]feed.Item, 5)}, time.Hour},
+		{"half the posting gap", feed.Feed{Items: itemsEvery(5, 4*time.Hour)}, 2 * time.Hour},
+		{"busy feeds are bounded by the minimum", feed.Feed{Items: itemsEvery(10, time.Minute)}, 5 * time.Minute},
+		{"quiet feeds are bounded by the maximum", feed.Feed{Items: itemsEvery(3, 30*24*time.Hour)}, 24 * time.Hour},
+		{"only the most recent items count", feed.Feed{Items: append(itemsEvery(historySize, 2*time.Hour), feed.Item{Published: time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)})}, time.Hour},
+		{"ttl is a lower bound", feed.Feed{Items: itemsEvery(5, 2*time.Hour), TTL: 3 * time.Hour}, 3 * time.Hour},
+		{"sy:updatePeriod is a lower bound", feed.Feed{Items: itemsEvery(5, 2*time.Hour), UpdateInterval: 6 * time.Hour}, 6 * time.Hour},
+		{"hints do not lift the interval past the maximum", feed.Feed{TTL: 48 * time.Hour}, 24 * time.Hour},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := testPolicy.Interval(&tt.feed); got != tt.want {
+				t.Errorf("Interval = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestClamp(t *testing.T) {
+	tests := []struct {
+		in, want time.Duration
+	}{
+		{0, time.Hour},
+		{-time.Minute, time.Hour},
+		{time.Minute, 5 * time.Minute},
+		{3 * time.Hour, 3 * time.Hour},
+		{72 * time.Hour, 24 * time.Hour},
+	}
+	for _, tt := range tests {
+		if got := testPolicy.Clamp(tt.in); got != tt.want {
+			t.Errorf("Clamp(%v) = %v, want %v", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	tests := []struct {
+		failures int
+		want     time.Duration
+	}{
+		{0, 5 * time.Minute},
+		{1, 5 * time.Minute},
+		{2, 10 * time.Minute},
+		{3, 20 * time.Minute},
+		{5, 80 * time.Minute},
+		{10, 24 * time.Hour},
+		{1000, 24 * time.Hour},
+	}
+	for _, tt := range tests {
+		if got := testPolicy.Backoff(tt.failures); got != tt.want {
+			t.Errorf("Backoff(%d) = %v, want %v", tt.failures, got, tt.want)
+		}
+	}
+}
+
+func TestNext(t *testing.T) {
+	// a Sunday
+	now := time.Date(2026, 3, 1, 10, 30, 0, 0, time.UTC)
+	allHours := make([]int, 24)
+	for h := range allHours {
+		allHours[h] = h
+	}
+
+	tests := []struct {
+		name     string
+		zone     *time.Location // of now, UTC when nil
+		interval time.Duration
+		feed     *feed.Feed
+		want     time.Time
+	}{
+		{"without a feed", nil, time.Hour, nil, now.Add(time.Hour)},
+		{"without hints", nil, time.Hour, &feed.Feed{}, now.Add(time.Hour)},
+		{"outside the skipped hours", nil, time.Hour, &feed.Feed{SkipHours: []int{3, 4}}, now.Add(time.Hour)},
+		{"moved to the end of a skipped hour", nil, time.Hour, &feed.Feed{SkipHours: []int{11}}, time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)},
+		{"moved past a run of skipped hours", nil, time.Hour, &feed.Feed{SkipHours: []int{11, 12, 13}}, time.Date(2026, 3, 1, 14, 0, 0, 0, time.UTC)},
+		{"moved past a skipped day", nil, time.Hour, &feed.Feed{SkipDays: []time.Weekday{time.Sunday}}, time.Date(2026, 3, 2, 0, 0, 0, 0, time.UTC)},
+		{"skipped hours are in GMT", time.FixedZone("UTC+5", 5*60*60), time.Hour, &feed.Feed{SkipHours: []int{11}}, time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)},
+		{"a feed that skips every hour is ignored", nil, time.Hour, &feed.Feed{SkipHours: allHours}, now.Add(time.Hour)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			from := now
+			if tt.zone != nil {
+				from = now.In(tt.zone)
+			}
+			if got := Next(from, tt.interval, tt.feed); !got.Equal(tt.want) {
+				t.Errorf("Next = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -31,4 +31,12 @@ WHERE feed_follows.user_id = $1;
 
 -- name: DeleteFeedFollowByUserAndFeedID :exec
 DELETE FROM feed_follows
-WHERE user_id = $1 AND feed_id = $2;
\ No newline at end of file
+WHERE user_id = $1 AND feed_id = $2;
+
+-- MoveFeedFollows copies every follow of one feed to another, skipping users who already follow the target
+-- name: MoveFeedFollows :exec
+INSERT INTO feed_follows (id, created_at, updated_at, user_id, feed_id)
+SELECT gen_random_uuid(), NOW(), NOW(), source.user_id, sqlc.arg(to_feed_id)::uuid
+FROM feed_follows AS source
+WHERE source.feed_id = sqlc.arg(from_feed_id)
+ON CONFLICT (user_id, feed_id) DO NOTHING;
\ No newline at end of file
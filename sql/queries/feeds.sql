@@ -12,16 +12,79 @@ SELECT id, created_at, updated_at, name, url, user_id
 FROM feeds
 WHERE url = $1;
 
--- name: MarkFeedFetched :exec
+-- name: UpdateFeedCacheHeaders :exec
 UPDATE feeds
-SET last_fetched_at = NOW(), updated_at = NOW()
+SET etag = $2, last_modified = $3, updated_at = NOW()
 WHERE id = $1;
 
--- name: GetNextFeedToFetch :one
-SELECT id, created_at, updated_at, name, url, user_id, last_fetched_at
+-- name: SetFeedEncoding :exec
+UPDATE feeds
+SET encoding = $2, updated_at = NOW()
+WHERE id = $1;
+
+-- name: SetFeedParseWarnings :exec
+UPDATE feeds
+SET parse_warnings = $2, updated_at = NOW()
+WHERE id = $1;
+
+-- name: SetFeedFetchFullContent :exec
+UPDATE feeds
+SET fetch_full_content = $2, updated_at = NOW()
+WHERE id = $1;
+
+-- ClaimNextFeed atomically picks the active feed that has been due the longest and leases it until lease_until, so no other worker picks it up while it is being fetched. SKIP LOCKED lets several aggregator instances claim feeds at once without handing out the same feed twice
+-- name: ClaimNextFeed :one
+UPDATE feeds
+SET last_fetched_at = sqlc.arg(now), next_fetch_at = sqlc.arg(lease_until), updated_at = NOW()
+WHERE id = (
+    SELECT id
+    FROM feeds
+    WHERE status = 'active' AND (next_fetch_at IS NULL OR next_fetch_at <= sqlc.arg(now))
+    ORDER BY next_fetch_at NULLS FIRST, last_fetched_at NULLS FIRST
+    LIMIT 1
+    FOR UPDATE SKIP LOCKED
+)
+RETURNING id, created_at, updated_at, name, url, user_id, last_fetched_at, etag, last_modified, next_fetch_at, fetch_interval_seconds, consecutive_failures, last_error, last_error_at, status, site_url, description, encoding, parse_warnings, fetch_full_content;
+
+-- SetFeedSchedule records a successful fetch: it stores when the feed is due again and resets its failure count
+-- name: SetFeedSchedule :exec
+UPDATE feeds
+SET next_fetch_at = $2, fetch_interval_seconds = $3, consecutive_failures = 0, updated_at = NOW()
+WHERE id = $1;
+
+-- RecordFeedFailure stores why a fetch failed and when to retry
+-- name: RecordFeedFailure :exec
+UPDATE feeds
+SET consecutive_failures = consecutive_failures + 1, last_error = $2, last_error_at = NOW(), next_fetch_at = $3, updated_at = NOW()
+WHERE id = $1;
+
+-- name: SetFeedStatus :exec
+UPDATE feeds
+SET status = $2, updated_at = NOW()
+WHERE id = $1;
+
+-- ResumeFeed reactivates a paused or dead feed with a clean failure count and schedules it right away
+-- name: ResumeFeed :exec
+UPDATE feeds
+SET status = 'active', consecutive_failures = 0, next_fetch_at = NULL, updated_at = NOW()
+WHERE id = $1;
+
+-- name: GetFeedsByStatus :many
+SELECT id, name, url, consecutive_failures, last_error, last_error_at
 FROM feeds
-ORDER BY last_fetched_at NULLS FIRST, updated_at ASC
-LIMIT 1;
+WHERE status = $1
+ORDER BY name;
 
+-- name: UpdateFeedURL :exec
+UPDATE feeds
+SET url = $2, updated_at = NOW()
+WHERE id = $1;
 
+-- name: DeleteFeed :exec
+DELETE FROM feeds
+WHERE id = $1;
 
+-- DeleteUnfollowedFeeds removes the feeds nobody follows any more, together with their posts. starred posts live on as their copies in saved_posts
+-- name: DeleteUnfollowedFeeds :execrows
+DELETE FROM feeds
+WHERE NOT EXISTS (SELECT 1 FROM feed_follows WHERE feed_follows.feed_id = feeds.id);
@@ -0,0 +1,9 @@
+-- name: DeletePostCategories :exec
+DELETE FROM post_categories
+WHERE post_id = $1;
+
+-- AddPostCategories tags a post with every category in the array, skipping the ones it already has
+-- name: AddPostCategories :exec
+INSERT INTO post_categories (post_id, category)
+SELECT sqlc.arg(post_id)::uuid, unnest(sqlc.arg(categories)::text[])
+ON CONFLICT DO NOTHING;
@@ -0,0 +1,20 @@
+-- name: DeletePostEnclosures :exec
+DELETE FROM post_enclosures
+WHERE post_id = $1;
+
+-- name: CreatePostEnclosure :exec
+INSERT INTO post_enclosures (post_id, position, url, mime_type, length, duration_seconds)
+VALUES ($1, $2, $3, $4, $5, $6);
+
+-- name: GetPostEnclosures :many
+SELECT post_id, position, url, mime_type, length, duration_seconds
+FROM post_enclosures
+WHERE post_id = $1
+ORDER BY position;
+
+-- GetEnclosuresForPosts loads the enclosures of several posts at once, e.g. one page of browse
+-- name: GetEnclosuresForPosts :many
+SELECT post_id, position, url, mime_type, length, duration_seconds
+FROM post_enclosures
+WHERE post_id = ANY(sqlc.arg(post_ids)::uuid[])
+ORDER BY post_id, position;
@@ -0,0 +1,32 @@
+-- MarkPostRead marks one post as read, as long as it belongs to a feed the user follows. it returns 0 when the post was already read or is not the user's
+-- name: MarkPostRead :execrows
+INSERT INTO post_reads (user_id, post_id, read_at)
+SELECT feed_follows.user_id, posts.id, NOW()
+FROM posts
+INNER JOIN feed_follows ON feed_follows.feed_id = posts.feed_id
+WHERE feed_follows.user_id = $1 AND posts.id = $2
+ON CONFLICT (user_id, post_id) DO NOTHING;
+
+-- MarkPostsRead marks the posts of the feeds the user follows as read, only those of one feed or published before a date when given. it returns how many posts were newly marked
+-- name: MarkPostsRead :execrows
+INSERT INTO post_reads (user_id, post_id, read_at)
+SELECT feed_follows.user_id, posts.id, NOW()
+FROM posts
+INNER JOIN feed_follows ON feed_follows.feed_id = posts.feed_id
+WHERE feed_follows.user_id = sqlc.arg(user_id)
+  AND (sqlc.narg(feed_id)::uuid IS NULL OR posts.feed_id = sqlc.narg(feed_id))
+  AND (sqlc.narg(before)::timestamptz IS NULL OR posts.published_at < sqlc.narg(before))
+ON CONFLICT (user_id, post_id) DO NOTHING;
+
+-- name: MarkPostUnread :execrows
+DELETE FROM post_reads
+WHERE user_id = $1 AND post_id = $2;
+
+-- MarkPostsUnread is the reverse of MarkPostsRead
+-- name: MarkPostsUnread :execrows
+DELETE FROM post_reads
+USING posts
+WHERE post_reads.post_id = posts.id
+  AND post_reads.user_id = sqlc.arg(user_id)
+  AND (sqlc.narg(feed_id)::uuid IS NULL OR posts.feed_id = sqlc.narg(feed_id))
+  AND (sqlc.narg(before)::timestamptz IS NULL OR posts.published_at < sqlc.narg(before));
@@ -1,12 +1,94 @@
+-- CreatePost upserts on (feed_id, guid). An existing post is only rewritten when it changed; unchanged posts return no row. full_content_pending only applies to new posts
 -- name: CreatePost :one
-INSERT INTO posts (id, created_at, updated_at, feed_id, title, url, description, published_at)
-VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
-RETURNING id, created_at, updated_at, feed_id, title, url, description, published_at;
+INSERT INTO posts (id, created_at, updated_at, feed_id, guid, title, url, description, published_at, published_at_estimated, authors, content, comments_url, episode, full_content_pending)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15)
+ON CONFLICT (feed_id, guid) DO UPDATE
+SET updated_at = EXCLUDED.updated_at,
+    title = EXCLUDED.title,
+    url = EXCLUDED.url,
+    description = EXCLUDED.description,
+    published_at = CASE WHEN EXCLUDED.published_at_estimated THEN posts.published_at ELSE EXCLUDED.published_at END,
+    published_at_estimated = posts.published_at_estimated AND EXCLUDED.published_at_estimated,
+    authors = EXCLUDED.authors,
+    content = EXCLUDED.content,
+    comments_url = EXCLUDED.comments_url,
+    episode = EXCLUDED.episode
+WHERE (posts.title, posts.url, posts.description, posts.authors, posts.content, posts.comments_url, posts.episode) IS DISTINCT FROM (EXCLUDED.title, EXCLUDED.url, EXCLUDED.description, EXCLUDED.authors, EXCLUDED.content, EXCLUDED.comments_url, EXCLUDED.episode)
+   OR (NOT EXCLUDED.published_at_estimated AND posts.published_at IS DISTINCT FROM EXCLUDED.published_at)
+RETURNING id, created_at, updated_at, feed_id, guid, title, url, description, published_at, published_at_estimated;
 
--- name: GetPostsForUser :many
-SELECT posts.id, posts.created_at, posts.updated_at, posts.feed_id, posts.title, posts.url, posts.description, posts.published_at
+-- GetPostForUser returns one post with everything read shows, as long as it belongs to a feed the user follows
+-- name: GetPostForUser :one
+SELECT posts.id, posts.title, posts.url, posts.description, posts.published_at, posts.published_at_estimated, posts.authors, posts.content, posts.full_content, feeds.name AS feed_name
 FROM posts
 INNER JOIN feeds ON posts.feed_id = feeds.id
-WHERE feeds.user_id = $1
+INNER JOIN feed_follows ON feed_follows.feed_id = posts.feed_id
+WHERE posts.id = $1 AND feed_follows.user_id = $2;
+
+-- GetPostsForUser returns the newest posts of the feeds the user follows. posts the user has read are left out unless include_read is set
+-- name: GetPostsForUser :many
+SELECT posts.id, posts.created_at, posts.updated_at, posts.feed_id, posts.title, posts.url, posts.description, posts.published_at, posts.published_at_estimated, posts.authors, posts.content, posts.comments_url, posts.episode,
+    COALESCE((SELECT string_agg(category, ', ' ORDER BY category) FROM post_categories WHERE post_categories.post_id = posts.id), '')::text AS categories,
+    (post_reads.post_id IS NOT NULL)::bool AS read
+FROM posts
+INNER JOIN feed_follows ON feed_follows.feed_id = posts.feed_id
+LEFT JOIN post_reads ON post_reads.post_id = posts.id AND post_reads.user_id = sqlc.arg(user_id)
+WHERE feed_follows.user_id = sqlc.arg(user_id)
+  AND (sqlc.arg(include_read)::bool OR post_reads.post_id IS NULL)
 ORDER BY posts.published_at DESC
-LIMIT $2 OFFSET $3;
\ No newline at end of file
+LIMIT sqlc.arg('limit') OFFSET sqlc.arg('offset');
+
+-- MovePosts reassigns the posts of one feed to another, leaving behind posts the target already has
+-- name: MovePosts :exec
+UPDATE posts
+SET feed_id = sqlc.arg(to_feed_id), updated_at = NOW()
+WHERE posts.feed_id = sqlc.arg(from_feed_id)
+  AND posts.guid NOT IN (SELECT existing.guid FROM posts AS existing WHERE existing.feed_id = sqlc.arg(to_feed_id));
+
+-- ClaimFullContentPosts picks up to limit posts whose article is still to be downloaded, newest first, and clears their pending flag so no other aggregator downloads them too. a post whose download fails is not tried again. posts of feeds that have left full content mode since are skipped
+-- name: ClaimFullContentPosts :many
+UPDATE posts
+SET full_content_pending = FALSE
+WHERE posts.id IN (
+    SELECT pending.id
+    FROM posts AS pending
+    INNER JOIN feeds ON pending.feed_id = feeds.id
+    WHERE pending.full_content_pending AND feeds.fetch_full_content
+    ORDER BY pending.created_at DESC
+    LIMIT $1
+    FOR UPDATE OF pending SKIP LOCKED
+)
+RETURNING posts.id, posts.url;
+
+-- name: SetPostFullContent :exec
+UPDATE posts
+SET full_content = $2, updated_at = NOW()
+WHERE id = $1;
+
+-- DeleteOldPosts removes the posts published before the cutoff, except those a user has starred, and keeps their guids in pruned_posts so the next fetch does not store them again. it returns how many posts were deleted, not how many guids were newly kept
+-- name: DeleteOldPosts :one
+WITH deleted AS (
+    DELETE FROM posts
+    WHERE posts.published_at < $1
+      AND NOT EXISTS (SELECT 1 FROM saved_posts WHERE saved_posts.post_id = posts.id)
+    RETURNING posts.feed_id, posts.guid
+), pruned AS (
+    INSERT INTO pruned_posts (feed_id, guid)
+    SELECT deleted.feed_id, deleted.guid FROM deleted
+    ON CONFLICT (feed_id, guid) DO NOTHING
+)
+SELECT count(*) FROM deleted;
+
+-- GetPrunedGUIDs returns which of the given guids belong to posts of the feed that prune deleted
+-- name: GetPrunedGUIDs :many
+SELECT guid
+FROM pruned_posts
+WHERE feed_id = sqlc.arg(feed_id) AND guid = ANY(sqlc.arg(guids)::text[]);
+
+-- MovePrunedPosts hands the pruned guids of one feed over to another when the feed moved
+-- name: MovePrunedPosts :exec
+INSERT INTO pruned_posts (feed_id, guid, pruned_at)
+SELECT sqlc.arg(to_feed_id)::uuid, source.guid, source.pruned_at
+FROM pruned_posts AS source
+WHERE source.feed_id = sqlc.arg(from_feed_id)
+ON CONFLICT (feed_id, guid) DO NOTHING;
@@ -0,0 +1,28 @@
+-- StarPost saves a post of a feed the user follows with an optional note, together with a copy of the post that outlives it. starring a post again replaces the note when a new one is given
+-- name: StarPost :one
+INSERT INTO saved_posts (id, user_id, post_id, notes, saved_at, title, url, content, feed_name, published_at)
+SELECT sqlc.arg(id)::uuid, feed_follows.user_id, posts.id, sqlc.narg(notes)::text, NOW(), posts.title, posts.url, COALESCE(posts.full_content, posts.content, posts.description), feeds.name, posts.published_at
+FROM posts
+INNER JOIN feeds ON posts.feed_id = feeds.id
+INNER JOIN feed_follows ON feed_follows.feed_id = posts.feed_id
+WHERE posts.id = sqlc.arg(post_id) AND feed_follows.user_id = sqlc.arg(user_id)
+ON CONFLICT (user_id, post_id) DO UPDATE
+SET notes = COALESCE(EXCLUDED.notes, saved_posts.notes)
+RETURNING id, user_id, post_id, notes, saved_at, title, url, content, feed_name, published_at;
+
+-- UnstarPost removes a star by the id of the post, or by the id of the star once the post itself is gone
+-- name: UnstarPost :execrows
+DELETE FROM saved_posts
+WHERE user_id = sqlc.arg(user_id) AND (post_id = sqlc.arg(id)::uuid OR id = sqlc.arg(id)::uuid);
+
+-- name: GetSavedPosts :many
+SELECT id, user_id, post_id, notes, saved_at, title, url, content, feed_name, published_at
+FROM saved_posts
+WHERE user_id = $1
+ORDER BY saved_at DESC;
+
+-- GetSavedPost finds a star by the id of the post or of the star
+-- name: GetSavedPost :one
+SELECT id, user_id, post_id, notes, saved_at, title, url, content, feed_name, published_at
+FROM saved_posts
+WHERE user_id = sqlc.arg(user_id) AND (post_id = sqlc.arg(id)::uuid OR id = sqlc.arg(id)::uuid);
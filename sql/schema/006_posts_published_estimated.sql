@@ -0,0 +1,8 @@
+-- add published_at_estimated to posts so items without a usable date can fall back to the fetch time
+-- +goose Up
+ALTER TABLE posts
+ADD COLUMN IF NOT EXISTS published_at_estimated BOOLEAN NOT NULL DEFAULT FALSE;
+
+-- +goose Down
+ALTER TABLE posts
+DROP COLUMN IF EXISTS published_at_estimated;
@@ -0,0 +1,26 @@
+-- identify posts by (feed_id, guid) instead of a globally unique url, so two feeds can link the same article and updated items can be upserted
+-- +goose Up
+ALTER TABLE posts
+ADD COLUMN IF NOT EXISTS guid TEXT;
+
+-- existing posts have no guid; most RSS guids are the permalink, so the url is the closest match
+UPDATE posts SET guid = url WHERE guid IS NULL;
+
+ALTER TABLE posts
+ALTER COLUMN guid SET NOT NULL;
+
+ALTER TABLE posts
+DROP CONSTRAINT IF EXISTS posts_url_key;
+
+ALTER TABLE posts
+ADD CONSTRAINT posts_feed_id_guid_key UNIQUE (feed_id, guid);
+
+-- +goose Down
+ALTER TABLE posts
+DROP CONSTRAINT IF EXISTS posts_feed_id_guid_key;
+
+ALTER TABLE posts
+DROP COLUMN IF EXISTS guid;
+
+ALTER TABLE posts
+ADD CONSTRAINT posts_url_key UNIQUE (url);
@@ -0,0 +1,14 @@
+-- schedule each feed individually: next_fetch_at is when it is due again and fetch_interval_seconds the interval chosen at the last fetch
+-- +goose Up
+ALTER TABLE feeds
+ADD COLUMN IF NOT EXISTS next_fetch_at TIMESTAMPTZ,
+ADD COLUMN IF NOT EXISTS fetch_interval_seconds INTEGER;
+
+CREATE INDEX IF NOT EXISTS feeds_next_fetch_at_idx ON feeds (next_fetch_at NULLS FIRST);
+
+-- +goose Down
+DROP INDEX IF EXISTS feeds_next_fetch_at_idx;
+
+ALTER TABLE feeds
+DROP COLUMN IF EXISTS next_fetch_at,
+DROP COLUMN IF EXISTS fetch_interval_seconds;
@@ -0,0 +1,12 @@
+-- track failing feeds so they can be backed off and reported by feeds:status
+-- +goose Up
+ALTER TABLE feeds
+ADD COLUMN IF NOT EXISTS consecutive_failures INTEGER NOT NULL DEFAULT 0,
+ADD COLUMN IF NOT EXISTS last_error TEXT,
+ADD COLUMN IF NOT EXISTS last_error_at TIMESTAMPTZ;
+
+-- +goose Down
+ALTER TABLE feeds
+DROP COLUMN IF EXISTS consecutive_failures,
+DROP COLUMN IF EXISTS last_error,
+DROP COLUMN IF EXISTS last_error_at;
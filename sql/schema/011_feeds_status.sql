@@ -0,0 +1,9 @@
+-- feeds are active (scheduled), paused (by a user) or dead (gone or failing for too long); only active feeds are fetched
+-- +goose Up
+ALTER TABLE feeds
+ADD COLUMN IF NOT EXISTS status TEXT NOT NULL DEFAULT 'active'
+CHECK (status IN ('active', 'paused', 'dead'));
+
+-- +goose Down
+ALTER TABLE feeds
+DROP COLUMN IF EXISTS status;
@@ -0,0 +1,10 @@
+-- store the site a feed belongs to and its description, as published by the feed itself
+-- +goose Up
+ALTER TABLE feeds
+ADD COLUMN IF NOT EXISTS site_url TEXT,
+ADD COLUMN IF NOT EXISTS description TEXT;
+
+-- +goose Down
+ALTER TABLE feeds
+DROP COLUMN IF EXISTS site_url,
+DROP COLUMN IF EXISTS description;
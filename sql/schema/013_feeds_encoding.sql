@@ -0,0 +1,8 @@
+-- remember the character encoding each feed was last published in
+-- +goose Up
+ALTER TABLE feeds
+ADD COLUMN IF NOT EXISTS encoding TEXT;
+
+-- +goose Down
+ALTER TABLE feeds
+DROP COLUMN IF EXISTS encoding;
@@ -0,0 +1,8 @@
+-- keep the problems found in each feed's last document, e.g. bare ampersands that had to be escaped
+-- +goose Up
+ALTER TABLE feeds
+ADD COLUMN IF NOT EXISTS parse_warnings TEXT;
+
+-- +goose Down
+ALTER TABLE feeds
+DROP COLUMN IF EXISTS parse_warnings;
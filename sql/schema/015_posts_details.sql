@@ -0,0 +1,20 @@
+-- keep the authors, full content and comments link of each post, separately from its summary, along with its categories
+-- +goose Up
+ALTER TABLE posts
+ADD COLUMN IF NOT EXISTS authors TEXT,
+ADD COLUMN IF NOT EXISTS content TEXT,
+ADD COLUMN IF NOT EXISTS comments_url TEXT;
+
+CREATE TABLE IF NOT EXISTS post_categories (
+    post_id UUID NOT NULL REFERENCES posts(id) ON DELETE CASCADE,
+    category TEXT NOT NULL,
+    PRIMARY KEY (post_id, category)
+);
+
+-- +goose Down
+DROP TABLE IF EXISTS post_categories;
+
+ALTER TABLE posts
+DROP COLUMN IF EXISTS authors,
+DROP COLUMN IF EXISTS content,
+DROP COLUMN IF EXISTS comments_url;
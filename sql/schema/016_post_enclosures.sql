@@ -0,0 +1,20 @@
+-- keep the media files attached to posts, e.g. podcast episodes, and the episode number
+-- +goose Up
+CREATE TABLE IF NOT EXISTS post_enclosures (
+    post_id UUID NOT NULL REFERENCES posts(id) ON DELETE CASCADE,
+    position INTEGER NOT NULL,
+    url TEXT NOT NULL,
+    mime_type TEXT,
+    length BIGINT,
+    duration_seconds INTEGER,
+    PRIMARY KEY (post_id, position)
+);
+
+ALTER TABLE posts
+ADD COLUMN IF NOT EXISTS episode INTEGER;
+
+-- +goose Down
+ALTER TABLE posts
+DROP COLUMN IF EXISTS episode;
+
+DROP TABLE IF EXISTS post_enclosures;
@@ -0,0 +1,14 @@
+-- let feeds that only publish a summary have the linked article downloaded, and keep its readable content with the post
+-- +goose Up
+ALTER TABLE feeds
+ADD COLUMN IF NOT EXISTS fetch_full_content BOOLEAN NOT NULL DEFAULT FALSE;
+
+ALTER TABLE posts
+ADD COLUMN IF NOT EXISTS full_content TEXT;
+
+-- +goose Down
+ALTER TABLE posts
+DROP COLUMN IF EXISTS full_content;
+
+ALTER TABLE feeds
+DROP COLUMN IF EXISTS fetch_full_content;
@@ -0,0 +1,11 @@
+-- remember which posts each user has read
+-- +goose Up
+CREATE TABLE IF NOT EXISTS post_reads (
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    post_id UUID NOT NULL REFERENCES posts(id) ON DELETE CASCADE,
+    read_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (user_id, post_id)
+);
+
+-- +goose Down
+DROP TABLE IF EXISTS post_reads;
@@ -0,0 +1,18 @@
+-- let users star posts to keep them. a copy of the post is kept with the star, so it survives pruning and the deletion of its feed
+-- +goose Up
+CREATE TABLE IF NOT EXISTS saved_posts (
+    id UUID PRIMARY KEY,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    post_id UUID REFERENCES posts(id) ON DELETE SET NULL,
+    notes TEXT,
+    saved_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    title TEXT NOT NULL,
+    url TEXT NOT NULL,
+    content TEXT,
+    feed_name TEXT NOT NULL,
+    published_at TIMESTAMPTZ NOT NULL,
+    UNIQUE (user_id, post_id)
+);
+
+-- +goose Down
+DROP TABLE IF EXISTS saved_posts;
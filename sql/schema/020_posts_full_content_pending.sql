@@ -0,0 +1,12 @@
+-- mark the posts whose article is still to be downloaded, so the aggregator fetches articles in a pass of their own rather than while it holds the claim on their feed
+-- +goose Up
+ALTER TABLE posts
+ADD COLUMN IF NOT EXISTS full_content_pending BOOLEAN NOT NULL DEFAULT FALSE;
+
+CREATE INDEX IF NOT EXISTS posts_full_content_pending_idx ON posts (created_at) WHERE full_content_pending;
+
+-- +goose Down
+DROP INDEX IF EXISTS posts_full_content_pending_idx;
+
+ALTER TABLE posts
+DROP COLUMN IF EXISTS full_content_pending;
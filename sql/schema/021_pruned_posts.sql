@@ -0,0 +1,11 @@
+-- remember the guids of the posts prune deleted, so they are not stored again while their feed still lists them
+-- +goose Up
+CREATE TABLE IF NOT EXISTS pruned_posts (
+    feed_id UUID NOT NULL REFERENCES feeds(id) ON DELETE CASCADE,
+    guid TEXT NOT NULL,
+    pruned_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (feed_id, guid)
+);
+
+-- +goose Down
+DROP TABLE IF EXISTS pruned_posts;